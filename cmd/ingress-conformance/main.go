@@ -0,0 +1,129 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// ingress-conformance runs the conformance suite inside the cluster. It creates
+// the namespace, RBAC and a pod running the conformance image, streams the logs
+// of the suite, copies the results (reports, summary.json and junit.xml) to a
+// local directory and exits with the exit code of the suite.
+//
+// Flags after -- are passed to the conformance suite:
+//
+//	go run ./cmd/ingress-conformance --image aledbf/ingress-conformance:dev -- --ingress-class=nginx
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"github.com/aledbf/ingress-conformance-bdd/test/utils"
+)
+
+type options struct {
+	image           string
+	imagePullPolicy string
+	namespace       string
+	timeout         time.Duration
+	outputDirectory string
+	cleanup         bool
+
+	// flags of the conformance suite, passed to the container as arguments
+	suiteArgs []string
+}
+
+func main() {
+	opts := &options{}
+
+	flag.StringVar(&opts.image, "image", "aledbf/ingress-conformance:latest", "image of the conformance suite (images/conformance)")
+	flag.StringVar(&opts.imagePullPolicy, "image-pull-policy", "IfNotPresent", "pull policy of the conformance image")
+	flag.StringVar(&opts.namespace, "namespace", utils.ConformanceNamespace, "namespace where the conformance pod runs")
+	flag.DurationVar(&opts.timeout, "timeout", 60*time.Minute, "maximum duration of the conformance suite")
+	flag.StringVar(&opts.outputDirectory, "output-directory", "results", "local directory where the results are copied")
+	flag.BoolVar(&opts.cleanup, "cleanup", true, "delete the namespace, RBAC and pod created when the suite finishes (existing objects are kept)")
+
+	flag.Parse()
+
+	opts.suiteArgs = flag.Args()
+
+	code, err := run(opts)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	os.Exit(code)
+}
+
+// run creates the conformance pod and returns the exit code of the suite
+func run(opts *options) (int, error) {
+	config, err := utils.LoadConfig()
+	if err != nil {
+		return 0, fmt.Errorf("error loading client configuration: %v", err)
+	}
+
+	c, err := utils.LoadClientset()
+	if err != nil {
+		return 0, fmt.Errorf("error loading client: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), opts.timeout)
+	defer cancel()
+
+	created := &createdResources{}
+
+	if opts.cleanup {
+		defer func() {
+			log.Printf("Deleting the resources created in namespace %v and RBAC...", opts.namespace)
+
+			cleanupCtx, cancel := context.WithTimeout(context.Background(), time.Minute)
+			defer cancel()
+
+			deleteResources(cleanupCtx, c, opts.namespace, created)
+		}()
+	}
+
+	log.Printf("Creating conformance pod %v/%v (image %v)...", opts.namespace, runnerName, opts.image)
+	if err := createResources(ctx, c, opts, created); err != nil {
+		return 0, err
+	}
+
+	_, err = waitForContainer(ctx, c, opts.namespace, runnerName, conformanceContainer, isStarted)
+	if err != nil {
+		return 0, fmt.Errorf("error waiting for conformance pod to start: %v", err)
+	}
+
+	if err := streamLogs(ctx, c, opts.namespace, runnerName, conformanceContainer, os.Stdout); err != nil {
+		log.Printf("Error streaming logs (the suite is still running): %v", err)
+	}
+
+	status, err := waitForContainer(ctx, c, opts.namespace, runnerName, conformanceContainer, isTerminated)
+	if err != nil {
+		return 0, fmt.Errorf("error waiting for the conformance suite to finish: %v", err)
+	}
+
+	code := int(status.State.Terminated.ExitCode)
+	log.Printf("Conformance suite finished with exit code %v", code)
+
+	if err := copyResults(config, c, opts.namespace, runnerName, opts.outputDirectory); err != nil {
+		return 0, err
+	}
+
+	log.Printf("Results copied to %v", opts.outputDirectory)
+
+	return code, nil
+}
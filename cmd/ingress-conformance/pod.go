@@ -0,0 +1,225 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/scheme"
+	restclient "k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/remotecommand"
+)
+
+const (
+	pollInterval = 2 * time.Second
+)
+
+// deletePod deletes a pod, if exists, waiting until is removed
+func deletePod(ctx context.Context, c kubernetes.Interface, namespace, name string) error {
+	err := c.CoreV1().Pods(namespace).Delete(ctx, name, metav1.DeleteOptions{})
+	if ignoreNotFound(err) != nil {
+		return fmt.Errorf("unable to delete pod %v: %v", name, err)
+	}
+
+	if err != nil {
+		return nil
+	}
+
+	log.Printf("Waiting for removal of pod %v from a previous run...", name)
+
+	return wait.PollImmediateUntil(pollInterval, func() (bool, error) {
+		_, err := c.CoreV1().Pods(namespace).Get(ctx, name, metav1.GetOptions{})
+		if ignoreNotFound(err) != nil {
+			return false, err
+		}
+
+		return err != nil, nil
+	}, ctx.Done())
+}
+
+// waitForContainer waits until the condition returns true for the status of
+// the container. While the container is waiting the reason is logged.
+func waitForContainer(ctx context.Context, c kubernetes.Interface, namespace, pod, container string,
+	condition func(*corev1.ContainerStatus) bool) (*corev1.ContainerStatus, error) {
+	var (
+		status     *corev1.ContainerStatus
+		lastReason string
+	)
+
+	err := wait.PollImmediateUntil(pollInterval, func() (bool, error) {
+		p, err := c.CoreV1().Pods(namespace).Get(ctx, pod, metav1.GetOptions{})
+		if err != nil {
+			return false, err
+		}
+
+		if p.Status.Phase == corev1.PodFailed && len(p.Status.ContainerStatuses) == 0 {
+			return false, fmt.Errorf("pod %v failed: %v", pod, p.Status.Message)
+		}
+
+		for i := range p.Status.ContainerStatuses {
+			cs := &p.Status.ContainerStatuses[i]
+			if cs.Name != container {
+				continue
+			}
+
+			if cs.State.Waiting != nil && cs.State.Waiting.Reason != lastReason {
+				lastReason = cs.State.Waiting.Reason
+				log.Printf("Container %v is waiting: %v %v", container, lastReason, cs.State.Waiting.Message)
+			}
+
+			status = cs
+			return condition(cs), nil
+		}
+
+		return false, nil
+	}, ctx.Done())
+
+	return status, err
+}
+
+func isStarted(cs *corev1.ContainerStatus) bool {
+	return cs.State.Running != nil || cs.State.Terminated != nil
+}
+
+func isTerminated(cs *corev1.ContainerStatus) bool {
+	return cs.State.Terminated != nil
+}
+
+// streamLogs copies the logs of the container to out until the container terminates
+func streamLogs(ctx context.Context, c kubernetes.Interface, namespace, pod, container string, out io.Writer) error {
+	stream, err := c.CoreV1().Pods(namespace).GetLogs(pod, &corev1.PodLogOptions{
+		Container: container,
+		Follow:    true,
+	}).Stream(ctx)
+	if err != nil {
+		return fmt.Errorf("unable to stream logs of pod %v: %v", pod, err)
+	}
+	defer stream.Close()
+
+	_, err = io.Copy(out, stream)
+	return err
+}
+
+// copyResults copies the results archive from the results container
+// and extracts the content in the output directory.
+func copyResults(config *restclient.Config, c kubernetes.Interface, namespace, pod, outputDirectory string) error {
+	archive := resultsDirectory + "/e2e.tar.gz"
+
+	req := c.CoreV1().RESTClient().Post().
+		Resource("pods").
+		Name(pod).
+		Namespace(namespace).
+		SubResource("exec").
+		VersionedParams(&corev1.PodExecOptions{
+			Container: resultsContainer,
+			Command:   []string{"cat", archive},
+			Stdout:    true,
+			Stderr:    true,
+		}, scheme.ParameterCodec)
+
+	exec, err := remotecommand.NewSPDYExecutor(config, "POST", req.URL())
+	if err != nil {
+		return err
+	}
+
+	stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+
+	err = exec.Stream(remotecommand.StreamOptions{
+		Stdout: stdout,
+		Stderr: stderr,
+	})
+	if err != nil {
+		return fmt.Errorf("unable to copy %v from pod %v: %v %v", archive, pod, err, strings.TrimSpace(stderr.String()))
+	}
+
+	if err := os.MkdirAll(outputDirectory, 0755); err != nil {
+		return err
+	}
+
+	data := stdout.Bytes()
+	if err := extract(bytes.NewReader(data), outputDirectory); err != nil {
+		return fmt.Errorf("unable to extract results: %v", err)
+	}
+
+	return writeFile(filepath.Join(outputDirectory, filepath.Base(archive)), bytes.NewReader(data))
+}
+
+// extract writes the regular files of a gzip compressed tar archive in a directory
+func extract(r io.Reader, dir string) error {
+	gr, err := gzip.NewReader(r)
+	if err != nil {
+		return err
+	}
+	defer gr.Close()
+
+	tr := tar.NewReader(gr)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+
+		if err != nil {
+			return err
+		}
+
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+
+		path := filepath.Join(dir, filepath.FromSlash(header.Name))
+		if !strings.HasPrefix(path, filepath.Clean(dir)+string(os.PathSeparator)) {
+			return fmt.Errorf("invalid file name in archive: %v", header.Name)
+		}
+
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			return err
+		}
+
+		if err := writeFile(path, tr); err != nil {
+			return err
+		}
+	}
+}
+
+func writeFile(path string, r io.Reader) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+
+	_, err = io.Copy(file, r)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+
+	return err
+}
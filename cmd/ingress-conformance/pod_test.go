@@ -0,0 +1,125 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+)
+
+type archiveEntry struct {
+	name     string
+	typeflag byte
+	content  string
+}
+
+func newArchive(t *testing.T, entries []archiveEntry) *bytes.Buffer {
+	buf := &bytes.Buffer{}
+
+	gw := gzip.NewWriter(buf)
+	tw := tar.NewWriter(gw)
+
+	for _, entry := range entries {
+		header := &tar.Header{
+			Name:     entry.name,
+			Typeflag: entry.typeflag,
+			Mode:     0644,
+			Size:     int64(len(entry.content)),
+		}
+
+		if entry.typeflag == tar.TypeDir {
+			header.Mode = 0755
+			header.Size = 0
+		}
+
+		if err := tw.WriteHeader(header); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if entry.typeflag == tar.TypeReg {
+			if _, err := tw.Write([]byte(entry.content)); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := gw.Close(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	return buf
+}
+
+func TestExtract(t *testing.T) {
+	dir := t.TempDir()
+
+	archive := newArchive(t, []archiveEntry{
+		{name: "reports", typeflag: tar.TypeDir},
+		{name: "summary.json", typeflag: tar.TypeReg, content: "{}"},
+		{name: "reports/host_matching-report.json", typeflag: tar.TypeReg, content: "[]"},
+	})
+
+	if err := extract(archive, dir); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	for name, expected := range map[string]string{
+		"summary.json":                      "{}",
+		"reports/host_matching-report.json": "[]",
+	} {
+		content, err := ioutil.ReadFile(filepath.Join(dir, filepath.FromSlash(name)))
+		if err != nil {
+			t.Errorf("expected the file %v extracted: %v", name, err)
+			continue
+		}
+
+		if string(content) != expected {
+			t.Errorf("expected the content %q in %v but got %q", expected, name, string(content))
+		}
+	}
+}
+
+func TestExtractInvalidArchive(t *testing.T) {
+	testCases := []struct {
+		name    string
+		archive *bytes.Buffer
+	}{
+		{
+			name:    "file outside the directory",
+			archive: newArchive(t, []archiveEntry{{name: "../summary.json", typeflag: tar.TypeReg, content: "{}"}}),
+		},
+		{
+			name:    "not compressed",
+			archive: bytes.NewBufferString("summary.json"),
+		},
+	}
+
+	for _, tc := range testCases {
+		dir := filepath.Join(t.TempDir(), "results")
+		if err := extract(tc.archive, dir); err == nil {
+			t.Errorf("%v: expected an error", tc.name)
+		}
+	}
+}
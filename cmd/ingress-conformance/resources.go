@@ -0,0 +1,299 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/aledbf/ingress-conformance-bdd/test/utils"
+)
+
+const (
+	// name of the service account, cluster role, cluster role binding and pod
+	runnerName = utils.ConformanceRBACName
+
+	// container running the conformance suite
+	conformanceContainer = "conformance"
+	// container that keeps the pod running until the results are copied
+	resultsContainer = "results"
+
+	resultsDirectory = "/tmp/results"
+)
+
+// runnerLabels identify the objects created to run the suite
+var runnerLabels = utils.ConformanceLabels
+
+func objectMeta(name, namespace string) metav1.ObjectMeta {
+	return metav1.ObjectMeta{
+		Name:      name,
+		Namespace: namespace,
+		Labels:    runnerLabels,
+	}
+}
+
+func newNamespace(namespace string) *corev1.Namespace {
+	return &corev1.Namespace{ObjectMeta: objectMeta(namespace, "")}
+}
+
+func newServiceAccount(namespace string) *corev1.ServiceAccount {
+	return &corev1.ServiceAccount{ObjectMeta: objectMeta(runnerName, namespace)}
+}
+
+// newClusterRole returns the role used by the conformance suite
+func newClusterRole() *rbacv1.ClusterRole {
+	return utils.ConformanceClusterRole()
+}
+
+func newClusterRoleBinding(namespace string) *rbacv1.ClusterRoleBinding {
+	return utils.ConformanceClusterRoleBinding(namespace)
+}
+
+// newRunnerPod returns the pod running the conformance suite. The results
+// are written in an emptyDir volume shared with a second container that
+// waits until the pod is deleted, allowing the copy of the results.
+func newRunnerPod(namespace string, opts *options) *corev1.Pod {
+	volumeMounts := []corev1.VolumeMount{
+		{
+			Name:      "results",
+			MountPath: resultsDirectory,
+		},
+	}
+
+	return &corev1.Pod{
+		ObjectMeta: objectMeta(runnerName, namespace),
+		Spec: corev1.PodSpec{
+			ServiceAccountName: runnerName,
+			RestartPolicy:      corev1.RestartPolicyNever,
+			Containers: []corev1.Container{
+				{
+					Name:            conformanceContainer,
+					Image:           opts.image,
+					ImagePullPolicy: corev1.PullPolicy(opts.imagePullPolicy),
+					Command:         []string{"/run_e2e.sh"},
+					Args:            opts.suiteArgs,
+					Env: []corev1.EnvVar{
+						{Name: "RESULTS_DIR", Value: resultsDirectory},
+						{
+							// the namespace of the pod is never removed by the suite
+							Name: utils.PodNamespaceEnv,
+							ValueFrom: &corev1.EnvVarSource{
+								FieldRef: &corev1.ObjectFieldSelector{FieldPath: "metadata.namespace"},
+							},
+						},
+					},
+					VolumeMounts: volumeMounts,
+				},
+				{
+					Name:            resultsContainer,
+					Image:           opts.image,
+					ImagePullPolicy: corev1.PullPolicy(opts.imagePullPolicy),
+					Command:         []string{"/bin/bash", "-c", "sleep infinity"},
+					VolumeMounts:    volumeMounts,
+				},
+			},
+			Volumes: []corev1.Volume{
+				{
+					Name: "results",
+					VolumeSource: corev1.VolumeSource{
+						EmptyDir: &corev1.EmptyDirVolumeSource{},
+					},
+				},
+			},
+		},
+	}
+}
+
+// createdResources records the objects created by the command. Objects
+// existing before the command runs are not removed by deleteResources.
+type createdResources struct {
+	namespace          bool
+	serviceAccount     bool
+	clusterRole        bool
+	clusterRoleBinding bool
+	pod                bool
+}
+
+// createResources creates the namespace, RBAC and the runner pod, recording the
+// objects created. Existing resources are reused, except the pod, that is replaced.
+// The rules of an existing cluster role are updated and an existing cluster role
+// binding must grant the cluster role to the service account of the namespace.
+func createResources(ctx context.Context, c kubernetes.Interface, opts *options, created *createdResources) error {
+	ns := opts.namespace
+
+	_, err := c.CoreV1().Namespaces().Create(ctx, newNamespace(ns), metav1.CreateOptions{})
+	created.namespace = err == nil
+	if err := ignoreAlreadyExists(err); err != nil {
+		return fmt.Errorf("unable to create namespace %v: %v", ns, err)
+	}
+
+	_, err = c.CoreV1().ServiceAccounts(ns).Create(ctx, newServiceAccount(ns), metav1.CreateOptions{})
+	created.serviceAccount = err == nil
+	if err := ignoreAlreadyExists(err); err != nil {
+		return fmt.Errorf("unable to create service account %v: %v", runnerName, err)
+	}
+
+	_, err = c.RbacV1().ClusterRoles().Create(ctx, newClusterRole(), metav1.CreateOptions{})
+	created.clusterRole = err == nil
+	if apierrors.IsAlreadyExists(err) {
+		err = updateClusterRole(ctx, c)
+	}
+
+	if err != nil {
+		return fmt.Errorf("unable to create cluster role %v: %v", runnerName, err)
+	}
+
+	_, err = c.RbacV1().ClusterRoleBindings().Create(ctx, newClusterRoleBinding(ns), metav1.CreateOptions{})
+	created.clusterRoleBinding = err == nil
+	if apierrors.IsAlreadyExists(err) {
+		err = checkClusterRoleBinding(ctx, c, ns)
+	}
+
+	if err != nil {
+		return fmt.Errorf("unable to create cluster role binding %v: %v", runnerName, err)
+	}
+
+	if err := deletePod(ctx, c, ns, runnerName); err != nil {
+		return err
+	}
+
+	_, err = c.CoreV1().Pods(ns).Create(ctx, newRunnerPod(ns, opts), metav1.CreateOptions{})
+	if err != nil {
+		return fmt.Errorf("unable to create pod %v: %v", runnerName, err)
+	}
+
+	created.pod = true
+
+	return nil
+}
+
+// updateClusterRole replaces the rules of an existing cluster role (i.e. created
+// by a previous version of the command) with the rules required by the suite
+func updateClusterRole(ctx context.Context, c kubernetes.Interface) error {
+	role, err := c.RbacV1().ClusterRoles().Get(ctx, runnerName, metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+
+	expected := newClusterRole()
+	if equality.Semantic.DeepEqual(role.Rules, expected.Rules) {
+		return nil
+	}
+
+	log.Printf("Updating the rules of the existing cluster role %v", runnerName)
+
+	role.Rules = expected.Rules
+	_, err = c.RbacV1().ClusterRoles().Update(ctx, role, metav1.UpdateOptions{})
+
+	return err
+}
+
+// checkClusterRoleBinding verifies an existing cluster role binding grants the
+// cluster role of the suite to the service account of the namespace. The binding
+// is not updated because it can be used by a run in a different namespace.
+func checkClusterRoleBinding(ctx context.Context, c kubernetes.Interface, namespace string) error {
+	binding, err := c.RbacV1().ClusterRoleBindings().Get(ctx, runnerName, metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+
+	expected := newClusterRoleBinding(namespace)
+	if binding.RoleRef != expected.RoleRef || !equality.Semantic.DeepEqual(binding.Subjects, expected.Subjects) {
+		return fmt.Errorf("the existing binding does not grant the cluster role %v to the service account %v/%v "+
+			"(roleRef %v %v, subjects %v). Delete it or use the namespace of the subjects",
+			runnerName, namespace, runnerName, binding.RoleRef.Kind, binding.RoleRef.Name, subjectNames(binding.Subjects))
+	}
+
+	return nil
+}
+
+func subjectNames(subjects []rbacv1.Subject) []string {
+	var names []string
+	for _, subject := range subjects {
+		if subject.Namespace == "" {
+			names = append(names, fmt.Sprintf("%v %v", subject.Kind, subject.Name))
+			continue
+		}
+
+		names = append(names, fmt.Sprintf("%v %v/%v", subject.Kind, subject.Namespace, subject.Name))
+	}
+
+	return names
+}
+
+// deleteResources removes the objects created by the command. If the namespace
+// existed before, only the pod and service account created are removed from it.
+func deleteResources(ctx context.Context, c kubernetes.Interface, namespace string, created *createdResources) {
+	background := metav1.DeletePropagationBackground
+	opts := metav1.DeleteOptions{PropagationPolicy: &background}
+
+	if created.clusterRoleBinding {
+		if err := c.RbacV1().ClusterRoleBindings().Delete(ctx, runnerName, opts); ignoreNotFound(err) != nil {
+			log.Printf("unable to delete cluster role binding %v: %v", runnerName, err)
+		}
+	}
+
+	if created.clusterRole {
+		if err := c.RbacV1().ClusterRoles().Delete(ctx, runnerName, opts); ignoreNotFound(err) != nil {
+			log.Printf("unable to delete cluster role %v: %v", runnerName, err)
+		}
+	}
+
+	if created.namespace {
+		if err := c.CoreV1().Namespaces().Delete(ctx, namespace, opts); ignoreNotFound(err) != nil {
+			log.Printf("unable to delete namespace %v: %v", namespace, err)
+		}
+
+		return
+	}
+
+	if created.pod {
+		if err := c.CoreV1().Pods(namespace).Delete(ctx, runnerName, opts); ignoreNotFound(err) != nil {
+			log.Printf("unable to delete pod %v: %v", runnerName, err)
+		}
+	}
+
+	if created.serviceAccount {
+		if err := c.CoreV1().ServiceAccounts(namespace).Delete(ctx, runnerName, opts); ignoreNotFound(err) != nil {
+			log.Printf("unable to delete service account %v: %v", runnerName, err)
+		}
+	}
+}
+
+func ignoreAlreadyExists(err error) error {
+	if apierrors.IsAlreadyExists(err) {
+		return nil
+	}
+
+	return err
+}
+
+func ignoreNotFound(err error) error {
+	if apierrors.IsNotFound(err) {
+		return nil
+	}
+
+	return err
+}
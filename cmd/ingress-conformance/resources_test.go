@@ -0,0 +1,231 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/aledbf/ingress-conformance-bdd/test/utils"
+)
+
+const testNamespace = "conformance-test"
+
+func testOptions() *options {
+	return &options{
+		image:           "example.com/conformance:dev",
+		imagePullPolicy: "Always",
+		namespace:       testNamespace,
+		suiteArgs:       []string{"--ingress-class=nginx", "--feature=host_*"},
+	}
+}
+
+func TestCreateAndDeleteResources(t *testing.T) {
+	testCases := []struct {
+		name     string
+		objects  []runtime.Object
+		expected createdResources
+		// objects existing after deleteResources
+		namespace, serviceAccount, clusterRole, clusterRoleBinding bool
+	}{
+		{
+			name:     "new resources",
+			expected: createdResources{true, true, true, true, true},
+		},
+		{
+			name: "existing namespace and service account",
+			objects: []runtime.Object{
+				&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: testNamespace}},
+				&corev1.ServiceAccount{ObjectMeta: metav1.ObjectMeta{Name: runnerName, Namespace: testNamespace}},
+			},
+			expected:       createdResources{false, false, true, true, true},
+			namespace:      true,
+			serviceAccount: true,
+		},
+		{
+			name: "existing RBAC",
+			objects: []runtime.Object{
+				newClusterRole(),
+				newClusterRoleBinding(testNamespace),
+			},
+			expected:           createdResources{true, true, false, false, true},
+			clusterRole:        true,
+			clusterRoleBinding: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := context.Background()
+			c := fake.NewSimpleClientset(tc.objects...)
+
+			created := &createdResources{}
+			if err := createResources(ctx, c, testOptions(), created); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if *created != tc.expected {
+				t.Errorf("expected created resources %+v but got %+v", tc.expected, *created)
+			}
+
+			if _, err := c.CoreV1().Pods(testNamespace).Get(ctx, runnerName, metav1.GetOptions{}); err != nil {
+				t.Errorf("expected the runner pod: %v", err)
+			}
+
+			deleteResources(ctx, c, testNamespace, created)
+
+			exists := func(err error) bool {
+				if ignoreNotFound(err) != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+
+				return err == nil
+			}
+
+			_, err := c.CoreV1().Namespaces().Get(ctx, testNamespace, metav1.GetOptions{})
+			if exists(err) != tc.namespace {
+				t.Errorf("expected namespace %v existing %v", testNamespace, tc.namespace)
+			}
+
+			// the fake clientset does not remove the content of deleted namespaces
+			if tc.namespace {
+				_, err = c.CoreV1().ServiceAccounts(testNamespace).Get(ctx, runnerName, metav1.GetOptions{})
+				if exists(err) != tc.serviceAccount {
+					t.Errorf("expected service account existing %v", tc.serviceAccount)
+				}
+
+				_, err = c.CoreV1().Pods(testNamespace).Get(ctx, runnerName, metav1.GetOptions{})
+				if exists(err) {
+					t.Errorf("expected the runner pod removed")
+				}
+			}
+
+			_, err = c.RbacV1().ClusterRoles().Get(ctx, runnerName, metav1.GetOptions{})
+			if exists(err) != tc.clusterRole {
+				t.Errorf("expected cluster role existing %v", tc.clusterRole)
+			}
+
+			_, err = c.RbacV1().ClusterRoleBindings().Get(ctx, runnerName, metav1.GetOptions{})
+			if exists(err) != tc.clusterRoleBinding {
+				t.Errorf("expected cluster role binding existing %v", tc.clusterRoleBinding)
+			}
+		})
+	}
+}
+
+func TestCreateResourcesStaleClusterRole(t *testing.T) {
+	ctx := context.Background()
+
+	stale := newClusterRole()
+	stale.Rules = []rbacv1.PolicyRule{{APIGroups: []string{""}, Resources: []string{"pods"}, Verbs: []string{"get"}}}
+
+	c := fake.NewSimpleClientset(stale)
+
+	if err := createResources(ctx, c, testOptions(), &createdResources{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	role, err := c.RbacV1().ClusterRoles().Get(ctx, runnerName, metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !reflect.DeepEqual(role.Rules, utils.ConformancePolicyRules) {
+		t.Errorf("expected the rules of the cluster role updated but got %v", role.Rules)
+	}
+}
+
+func TestCreateResourcesInvalidClusterRoleBinding(t *testing.T) {
+	otherNamespace := newClusterRoleBinding("other-namespace")
+
+	otherRole := newClusterRoleBinding(testNamespace)
+	otherRole.RoleRef.Name = "cluster-admin"
+
+	for _, binding := range []*rbacv1.ClusterRoleBinding{otherNamespace, otherRole} {
+		c := fake.NewSimpleClientset(binding)
+
+		created := &createdResources{}
+
+		err := createResources(context.Background(), c, testOptions(), created)
+		if err == nil || !strings.Contains(err.Error(), "does not grant the cluster role") {
+			t.Errorf("expected an error using the binding %+v but got %v", binding, err)
+		}
+
+		if created.clusterRoleBinding || created.pod {
+			t.Errorf("unexpected resources created %+v", *created)
+		}
+	}
+}
+
+func TestNewRunnerPod(t *testing.T) {
+	opts := testOptions()
+	pod := newRunnerPod(testNamespace, opts)
+
+	if pod.Name != runnerName || pod.Namespace != testNamespace || pod.Spec.ServiceAccountName != runnerName {
+		t.Errorf("unexpected pod %v/%v using service account %v", pod.Namespace, pod.Name, pod.Spec.ServiceAccountName)
+	}
+
+	if pod.Spec.RestartPolicy != corev1.RestartPolicyNever {
+		t.Errorf("expected restart policy Never but got %v", pod.Spec.RestartPolicy)
+	}
+
+	if len(pod.Spec.Containers) != 2 {
+		t.Fatalf("expected the conformance and results containers but got %v", len(pod.Spec.Containers))
+	}
+
+	suite := pod.Spec.Containers[0]
+	if suite.Name != conformanceContainer || suite.Image != opts.image || suite.ImagePullPolicy != corev1.PullAlways {
+		t.Errorf("unexpected conformance container %+v", suite)
+	}
+
+	if !reflect.DeepEqual(suite.Args, opts.suiteArgs) {
+		t.Errorf("expected the flags of the suite as arguments %v but got %v", opts.suiteArgs, suite.Args)
+	}
+
+	env := map[string]corev1.EnvVar{}
+	for _, e := range suite.Env {
+		env[e.Name] = e
+	}
+
+	if env["RESULTS_DIR"].Value != resultsDirectory {
+		t.Errorf("expected the results directory %v but got %v", resultsDirectory, env["RESULTS_DIR"].Value)
+	}
+
+	if ref := env[utils.PodNamespaceEnv].ValueFrom; ref == nil || ref.FieldRef.FieldPath != "metadata.namespace" {
+		t.Errorf("expected the namespace of the pod in %v", utils.PodNamespaceEnv)
+	}
+
+	// both containers share the results volume
+	for _, container := range pod.Spec.Containers {
+		if len(container.VolumeMounts) != 1 || container.VolumeMounts[0].MountPath != resultsDirectory {
+			t.Errorf("expected the results volume in container %v but got %v", container.Name, container.VolumeMounts)
+		}
+	}
+
+	// the label of the scenario namespaces removed by the suite
+	if pod.Labels[utils.NamespaceNameLabel] == "ingress-conformance" {
+		t.Errorf("expected a label different from the scenario namespaces but got %v", pod.Labels)
+	}
+}
@@ -0,0 +1,109 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// report renders the cucumber JSON reports generated by the conformance
+// suite (go test --format cucumber) as a static HTML site, including
+// the summary (summary.json and junit.xml) and the trend of previous runs.
+package main
+
+import (
+	"flag"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/aledbf/ingress-conformance-bdd/test/report"
+)
+
+func main() {
+	var (
+		inputDirectory  string
+		outputDirectory string
+		trendFile       string
+		trendSize       int
+		build           string
+		serve           string
+	)
+
+	flag.StringVar(&inputDirectory, "input-directory", "reports", "directory containing the cucumber JSON reports (*-report.json)")
+	flag.StringVar(&outputDirectory, "output-directory", "reports/output", "directory where the HTML report is generated")
+	flag.StringVar(&trendFile, "trend", "", "JSON file containing the results of previous runs (default <output-directory>/trend.json)")
+	flag.IntVar(&trendSize, "trend-size", 30, "number of runs kept in the trend file")
+	flag.StringVar(&build, "build", "", "name of the run in the trend (default current date)")
+	flag.StringVar(&serve, "serve", "", "address of a web server to browse the report (i.e. :8080)")
+
+	flag.Parse()
+
+	now := time.Now()
+
+	if trendFile == "" {
+		trendFile = filepath.Join(outputDirectory, "trend.json")
+	}
+
+	if build == "" {
+		build = now.UTC().Format("20060102-150405")
+	}
+
+	features, err := report.LoadDirectory(inputDirectory)
+	if err != nil {
+		log.Fatalf("Unexpected error reading reports: %v", err)
+	}
+
+	summary := report.NewSummary(features)
+
+	// summary written by the suite (--summary) in the directory of the reports
+	suite, err := report.LoadSummary(filepath.Join(inputDirectory, report.SummaryFile))
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if suite != nil {
+		summary.SetSuiteResults(suite)
+	}
+
+	trend, err := report.LoadTrend(trendFile)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	trend = report.AppendRun(trend, report.NewRun(build, now, summary), trendSize)
+
+	if err := report.WriteHTML(outputDirectory, features, summary, trend); err != nil {
+		log.Fatalf("Unexpected error generating HTML report: %v", err)
+	}
+
+	if err := summary.WriteFiles(outputDirectory); err != nil {
+		log.Fatalf("Unexpected error writing summary: %v", err)
+	}
+
+	if err := report.SaveTrend(trendFile, trend); err != nil {
+		log.Fatal(err)
+	}
+
+	log.Printf("Report generated in %v: %v (%v)", outputDirectory, summary, summary.Status)
+
+	if serve == "" {
+		return
+	}
+
+	log.Printf("Serving report in http://%v", serve)
+	if err := http.ListenAndServe(serve, http.FileServer(http.Dir(outputDirectory))); err != nil {
+		log.Printf("Unexpected error serving report: %v", err)
+		os.Exit(1)
+	}
+}
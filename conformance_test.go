@@ -17,25 +17,47 @@ limitations under the License.
 package main
 
 import (
+	"bytes"
 	"flag"
 	"fmt"
+	"io"
 	"log"
 	"os"
+	"os/signal"
 	"path"
 	"path/filepath"
+	"sync"
+	"syscall"
 	"testing"
+	"time"
 
 	"github.com/cucumber/godog"
 	clientset "k8s.io/client-go/kubernetes"
 	"k8s.io/klog"
 
-	"github.com/aledbf/ingress-conformance-bdd/test/conformance/defaultbackend"
-	"github.com/aledbf/ingress-conformance-bdd/test/conformance/withouthost"
+	"github.com/aledbf/ingress-conformance-bdd/test/conformance"
+	"github.com/aledbf/ingress-conformance-bdd/test/conformance/level"
+	"github.com/aledbf/ingress-conformance-bdd/test/preflight"
+	"github.com/aledbf/ingress-conformance-bdd/test/report"
+	tstate "github.com/aledbf/ingress-conformance-bdd/test/state"
+	"github.com/aledbf/ingress-conformance-bdd/test/steps"
 	"github.com/aledbf/ingress-conformance-bdd/test/utils"
 )
 
 var (
 	exitCode int
+
+	// serializes writes to stdout from features running concurrently
+	stdoutMu sync.Mutex
+
+	// cucumber JSON reports written by the features
+	reportFiles   []string
+	reportFilesMu sync.Mutex
+
+	// ensures the results are written once (suite finished or SIGTERM)
+	finishOnce sync.Once
+	// error that stopped the suite, recorded in the results directory
+	suiteErr error
 )
 
 var (
@@ -44,8 +66,41 @@ var (
 	godogStopOnFailure bool
 	godogNoColors      bool
 	godogOutput        string
+	godogConcurrency   int
+	// number of scenarios of a feature to run in parallel
+	scenarioConcurrency int
+
+	summary          bool
+	resultsDirectory string
+
+	skipPreflight    bool
+	preflightTimeout time.Duration
+	deleteNamespaces bool
+
+	manifests     string
+	featuresDir   string
+	featureFilter string
+	profileFile   string
+	levelName     string
 
-	manifests string
+	// conformance level of the features to run (flag --level)
+	runLevel level.Level
+	// highest conformance level passed in full
+	passedLevel level.Level
+
+	// capabilities of the ingress controller (flag --profile)
+	profile *conformance.Profile
+	// feature files checked against the profile, to report the scenarios it does not support
+	profiledFeatures   []string
+	profiledFeaturesMu sync.Mutex
+
+	// features selected using the flag --feature
+	selectedFeatures []conformance.Feature
+
+	// directory containing the feature files
+	featuresPath string
+	// removes the features written in a temporary directory
+	cleanupFeatures func()
 )
 
 func TestMain(m *testing.M) {
@@ -54,49 +109,228 @@ func TestMain(m *testing.M) {
 
 	flag.StringVar(&godogFormat, "format", "pretty", "Set godog format to use")
 	flag.StringVar(&godogTags, "tags", "", "Tags for conformance test")
-	flag.BoolVar(&godogStopOnFailure, "stop-on-failure ", false, "Stop when failure is found")
+	flag.BoolVar(&godogStopOnFailure, "stop-on-failure", false, "Stop when failure is found")
 	flag.BoolVar(&godogNoColors, "no-colors", false, "Disable colors in godog output")
-	flag.StringVar(&manifests, "manifests", "./manifests",
-		"Directory where manifests for test applications or scenerarios are located")
+	flag.StringVar(&manifests, "manifests", "",
+		"Directory where manifests for test applications or scenerarios are located. By default the manifests embedded in the binary are used")
+	flag.StringVar(&featuresDir, "features", "",
+		"Directory where the feature files are located. By default the features embedded in the binary are used")
+	flag.StringVar(&featureFilter, "feature", "",
+		"Comma separated list of features to run, using names (i.e. host_matching) or globs (i.e. 'host_*'). By default all the features run")
+	flag.StringVar(&profileFile, "profile", "",
+		"YAML file describing the capabilities of the ingress controller (supported and unsupported tags)")
+	flag.StringVar(&levelName, "level", string(level.ImplementationSpecific),
+		"Conformance level to run (core, extended or implementation-specific). A level includes the features of the lower levels")
 	flag.StringVar(&godogOutput, "output-directory", ".", "Output directory for test reports")
+	flag.BoolVar(&summary, "summary", false,
+		"Write a summary of the results (summary.json and junit.xml) in the output directory (requires --format cucumber)")
+	flag.StringVar(&resultsDirectory, "results-directory", "",
+		"Directory where reports, e2e.tar.gz and the done file are written (sonobuoy plugin). Implies --format cucumber and --summary")
+	flag.IntVar(&godogConcurrency, "concurrency", 1,
+		"Number of features to run in parallel. Each scenario uses a different namespace")
+	flag.IntVar(&scenarioConcurrency, "scenario-concurrency", 1,
+		"Number of scenarios of a feature to run in parallel. Each scenario uses a new state")
 	flag.StringVar(&utils.IngressClassValue, "ingress-class", "conformance",
-		"Sets the value of the annotation kubernetes.io/ingress.class in Ingress definitions")
+		"Sets the value of the annotation kubernetes.io/ingress.class (or spec.ingressClassName) in Ingress definitions")
+	flag.BoolVar(&utils.UseIngressClass, "use-ingress-class", false,
+		"Use an IngressClass and spec.ingressClassName instead of the annotation kubernetes.io/ingress.class")
+	flag.StringVar(&utils.IngressControllerName, "ingress-controller", "",
+		"Sets the value of spec.controller in the IngressClass (required with --use-ingress-class)")
+	flag.BoolVar(&skipPreflight, "skip-preflight", false,
+		"Skip the preflight checks of the cluster (nodes, ingress controller and images)")
+	flag.DurationVar(&preflightTimeout, "preflight-timeout", 2*time.Minute,
+		"Maximum duration of each preflight check")
+	flag.BoolVar(&deleteNamespaces, "delete-namespaces", false,
+		"Delete the namespaces of previous runs (ingress-conformance-*) instead of failing the preflight checks")
+	flag.StringVar(&utils.EchoServerImage, "echo-image", utils.EchoServerImage,
+		"Image of the echo server used as backend in scenarios")
+	flag.DurationVar(&tstate.DefaultRetryTimeout, "retry-timeout", tstate.DefaultRetryTimeout,
+		"Maximum duration of the steps sending requests until a condition holds (eventually)")
+	flag.DurationVar(&tstate.DefaultRetryInterval, "retry-interval", tstate.DefaultRetryInterval,
+		"Time to wait between the requests sent by the steps using eventually")
 
 	flag.Parse()
 
-	manifestsPath, err := filepath.Abs(manifests)
+	if resultsDirectory != "" {
+		if err := setupResultsDirectory(); err != nil {
+			fatalf("%v", err)
+		}
+	}
+
+	var err error
+
+	utils.Manifests, err = loadManifests(manifests)
 	if err != nil {
-		log.Fatal(err)
+		fatalf("%v", err)
 	}
 
-	if !utils.IsDir(manifestsPath) {
-		log.Fatalf("The specified value in the flag --manifests-directory (%v) is not a directory", manifests)
+	featuresPath, cleanupFeatures, err = loadFeatures(featuresDir)
+	if err != nil {
+		fatalf("%v", err)
 	}
 
-	utils.ManifestPath = manifestsPath
+	if err := conformance.Validate(conformance.Features, featuresPath); err != nil {
+		fatalf("%v", err)
+	}
 
-	utils.KubeClient, err = setupSuite()
+	selectedFeatures, err = conformance.Select(conformance.Features, conformance.SplitList(featureFilter))
 	if err != nil {
-		log.Fatal(err)
+		fatalf("%v", err)
 	}
 
-	if err := utils.CleanupNamespaces(utils.KubeClient); err != nil {
-		log.Fatalf("error deleting temporal namespaces: %v", err)
+	if profileFile != "" {
+		profile, err = conformance.LoadProfile(profileFile)
+		if err != nil {
+			fatalf("%v", err)
+		}
+
+		godogTags = conformance.AndTags(godogTags, profile.Tags())
+		log.Printf("Using profile of controller %v (tags: %v)", profile.Controller, profile.Tags())
+	}
+
+	runLevel, err = level.Parse(levelName)
+	if err != nil {
+		fatalf("%v", err)
+	}
+
+	godogTags = conformance.AndTags(godogTags, runLevel.Tags())
+
+	if godogConcurrency < 1 {
+		fatalf("The value of the flag --concurrency must be greater than zero (%v)", godogConcurrency)
+	}
+
+	if scenarioConcurrency < 1 {
+		fatalf("The value of the flag --scenario-concurrency must be greater than zero (%v)", scenarioConcurrency)
+	}
+
+	if summary && godogFormat != "cucumber" {
+		fatalf("The flag --summary requires the cucumber format (--format cucumber)")
+	}
+
+	if !utils.UseIngressClass {
+		// features using IngressClass resources require spec.ingressClassName
+		godogTags = conformance.ExcludeTag(godogTags, ingressClassTag)
+	}
+
+	utils.KubeClient, err = setupSuite()
+	if err != nil {
+		fatalf("%v", err)
 	}
 
 	if code := m.Run(); code > exitCode {
 		exitCode = code
 	}
 
-	os.Exit(exitCode)
+	finish(exitCode)
+}
+
+// setupResultsDirectory configures the suite to write all the reports in the
+// results directory. The results are saved when the suite finishes, fails to
+// start or receives a SIGTERM (i.e. the sonobuoy plugin is terminated).
+func setupResultsDirectory() error {
+	if err := os.MkdirAll(resultsDirectory, 0755); err != nil {
+		return fmt.Errorf("error creating results directory %v: %v", resultsDirectory, err)
+	}
+
+	godogFormat = "cucumber"
+	godogOutput = resultsDirectory
+	summary = true
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGTERM)
+
+	go func() {
+		<-signals
+		log.Printf("SIGTERM received, saving results in %v", resultsDirectory)
+		finish(1)
+	}()
+
+	return nil
 }
 
-func setupSuite() (*clientset.Clientset, error) {
-	c, err := utils.LoadClientset()
+// fatalf logs the error and finishes the suite with a non-zero exit code
+func fatalf(format string, args ...interface{}) {
+	suiteErr = fmt.Errorf(format, args...)
+	log.Print(suiteErr)
+	finish(1)
+}
+
+// finish writes the summary and saves the results directory, if
+// configured, before exiting the process with the specified code.
+func finish(code int) {
+	finishOnce.Do(func() {
+		if cleanupFeatures != nil {
+			cleanupFeatures()
+		}
+
+		// IngressClass created by the preflight checks (--use-ingress-class)
+		if utils.KubeClient != nil {
+			if err := utils.DeleteSuiteIngressClass(utils.KubeClient); err != nil {
+				log.Printf("error deleting IngressClass %v: %v", utils.IngressClassValue, err)
+			}
+		}
+
+		if summary {
+			if err := writeSummary(); err != nil {
+				log.Printf("error writing summary: %v", err)
+				code = 1
+			}
+		}
+
+		if resultsDirectory != "" {
+			code = saveResults(code)
+		}
+
+		os.Exit(code)
+	})
+}
+
+// saveResults archives the results directory, recording the error that
+// stopped the suite. The done file is written even if the archive cannot
+// be created, otherwise the sonobuoy worker waits until the plugin timeout.
+func saveResults(code int) int {
+	if suiteErr != nil {
+		if _, err := report.WriteError(resultsDirectory, suiteErr); err != nil {
+			log.Printf("error writing the error of the suite: %v", err)
+		}
+	}
+
+	err := report.SaveResults(resultsDirectory)
+	if err == nil {
+		return code
+	}
+
+	log.Printf("error saving results: %v", err)
+
+	path, err := report.WriteError(resultsDirectory, err)
+	if err != nil {
+		log.Printf("error writing the error saving results: %v", err)
+		return 1
+	}
+
+	if err := report.WriteDone(resultsDirectory, path); err != nil {
+		log.Printf("%v", err)
+	}
+
+	return 1
+}
+
+func setupSuite() (clientset.Interface, error) {
+	config, err := utils.LoadConfig()
+	if err != nil {
+		return nil, fmt.Errorf("error loading client configuration: %v", err)
+	}
+
+	c, err := clientset.NewForConfig(config)
 	if err != nil {
 		return nil, fmt.Errorf("error loading client: %v", err)
 	}
 
+	utils.ObjectsClient, err = utils.NewObjectClient(config, c)
+	if err != nil {
+		return nil, fmt.Errorf("error loading dynamic client: %v", err)
+	}
+
 	dc := c.DiscoveryClient
 
 	serverVersion, serverErr := dc.ServerVersion()
@@ -108,50 +342,338 @@ func setupSuite() (*clientset.Clientset, error) {
 		log.Printf("kube-apiserver version: %s", serverVersion.GitVersion)
 	}
 
+	checks := preflight.SetupChecks(deleteNamespaces, preflightTimeout)
+	if !skipPreflight {
+		checks = append(checks, preflight.ClusterChecks(utils.Manifests, preflightTimeout)...)
+	}
+
+	log.Printf("Running preflight checks...")
+
+	report := preflight.Run(c, checks)
+	log.Printf("Preflight checks:\n%v", report)
+
+	if report.Failed() {
+		return nil, fmt.Errorf("preflight checks failed")
+	}
+
 	return c, nil
 }
 
-var (
-	features = map[string]func(*godog.Suite){
-		"features/default_backend.feature": defaultbackend.FeatureContext,
-		"features/without_host.feature":    withouthost.FeatureContext,
+// writeSummary consolidates the cucumber JSON reports of the features
+// in summary.json and junit.xml, located in the output directory
+func writeSummary() error {
+	reportFilesMu.Lock()
+	defer reportFilesMu.Unlock()
+
+	profiledFeaturesMu.Lock()
+	defer profiledFeaturesMu.Unlock()
+
+	if len(reportFiles) == 0 && len(profiledFeatures) == 0 {
+		return fmt.Errorf("no features were executed")
 	}
-)
 
+	features, err := report.ParseFiles(reportFiles...)
+	if err != nil {
+		return err
+	}
+
+	s := report.NewSummary(features)
+	if profile != nil {
+		s.Controller = profile.Controller
+
+		s.Unsupported, err = report.UnsupportedScenarios(profile, profiledFeatures...)
+		if err != nil {
+			return err
+		}
+	}
+
+	s.Level = string(passedLevel)
+
+	if err := s.WriteFiles(godogOutput); err != nil {
+		return err
+	}
+
+	log.Printf("Summary: %v (%v)", s, s.Status)
+
+	return nil
+}
+
+// ingressClassTag identifies features that require IngressClass resources
+const ingressClassTag = "@ingress-class"
+
+// TestSuite runs each feature as a subtest, in the order defined in the registry
+// of features. Failed features do not stop the execution of the remaining ones
+// unless the flag --stop-on-failure is set.
 func TestSuite(t *testing.T) {
-	for feature, featureContext := range features {
-		//TODO: refactor to remove the defer
-		func() {
-			output := os.Stdout
-			if godogFormat == "cucumber" {
-				rf := path.Join(godogOutput, fmt.Sprintf("%v-report.json", filepath.Base(feature)))
-				file, err := os.Create(rf)
+	var (
+		mu     sync.Mutex
+		failed bool
+	)
+
+	results := make([]conformance.FeatureResult, len(selectedFeatures))
+
+	// limit the number of features running at the same time
+	queue := make(chan struct{}, godogConcurrency)
+
+	// parallel subtests finish before the group returns
+	t.Run("features", func(t *testing.T) {
+		for i, feature := range selectedFeatures {
+			i, feature := i, feature
+
+			t.Run(feature.Name(), func(t *testing.T) {
+				serial, err := conformance.IsSerial(filepath.Join(featuresPath, filepath.Base(feature.Path)))
 				if err != nil {
-					t.Fatalf("Error creating report file %v: %v", rf, err)
+					results[i] = conformance.FeatureResult{Feature: feature.Path, Status: conformance.FeatureFailed, Error: err}
+					t.Fatalf("Error reading feature %v: %v", feature.Path, err)
+				}
+
+				// serial features run before the parallel ones start
+				if godogConcurrency > 1 && !serial {
+					t.Parallel()
 				}
 
-				output = file
+				queue <- struct{}{}
 				defer func() {
-					_ = file.Sync()
-					_ = file.Close()
+					<-queue
 				}()
-			}
 
-			exitCode += godog.RunWithOptions("conformance", func(s *godog.Suite) {
-				featureContext(s)
-			}, godog.Options{
-				Format:        godogFormat,
-				Paths:         []string{feature},
-				Tags:          godogTags,
-				StopOnFailure: godogStopOnFailure,
-				NoColors:      godogNoColors,
-				Output:        output,
-				Concurrency:   1, // do not run tests concurrently
+				result := conformance.FeatureResult{Feature: feature.Path, Status: conformance.FeatureSkipped}
+
+				featureLevel, excluded, err := inspectFeature(feature)
+				if err != nil {
+					results[i] = conformance.FeatureResult{Feature: feature.Path, Status: conformance.FeatureFailed, Error: err}
+					t.Fatalf("Error reading feature %v: %v", feature.Path, err)
+				}
+
+				result.Level = featureLevel
+				result.Excluded = excluded
+
+				mu.Lock()
+				result.Reason = conformance.SkipReason(featureLevel, runLevel, failed, godogStopOnFailure)
+				mu.Unlock()
+
+				switch result.Reason {
+				case "":
+				case conformance.SkippedAfterFailure:
+					results[i] = result
+					t.Skip("skipped after a failed feature (--stop-on-failure)")
+				default:
+					results[i] = result
+					t.Skipf("skipped: %v (--level %v)", result.Reason, runLevel)
+				}
+
+				unsupported, err := isUnsupported(feature)
+				if err != nil {
+					results[i] = conformance.FeatureResult{Feature: feature.Path, Status: conformance.FeatureFailed, Level: featureLevel, Error: err}
+					t.Fatalf("Error checking profile of feature %v: %v", feature.Path, err)
+				}
+
+				if unsupported {
+					result.Reason = conformance.SkippedUnsupported
+					results[i] = result
+					t.Skipf("skipped: %v (%v)", conformance.SkippedUnsupported, profile.Controller)
+				}
+
+				concurrency := scenarioConcurrency
+				if serial {
+					concurrency = 1
+				}
+
+				start := time.Now()
+				code, err := runFeature(feature, concurrency)
+
+				result.Status = conformance.FeaturePassed
+				result.Duration = time.Since(start)
+				result.Error = err
+
+				if err != nil || code != 0 {
+					result.Status = conformance.FeatureFailed
+
+					mu.Lock()
+					failed = true
+					mu.Unlock()
+				}
+
+				results[i] = result
+
+				if err != nil {
+					t.Fatalf("Error running feature %v: %v", feature.Path, err)
+				}
+
+				if code != 0 {
+					t.Fatalf("Feature %v failed", feature.Path)
+				}
 			})
+		}
+	})
+
+	passedLevel = conformance.LevelPassed(results, runLevel)
 
-			if exitCode != 0 {
-				t.Fatalf("Error encountered running the test suite")
+	log.Printf("Features:\n%v", conformance.FormatResults(results, passedLevel, featureFilter != ""))
+
+	if code := conformance.ExitCode(results); code > exitCode {
+		exitCode = code
+	}
+}
+
+// inspectFeature returns the conformance level of a feature and the
+// number of scenarios excluded by the tag expression of the suite
+func inspectFeature(feature conformance.Feature) (level.Level, int, error) {
+	scenarios, err := conformance.ScenarioTags(filepath.Join(featuresPath, filepath.Base(feature.Path)))
+	if err != nil {
+		return "", 0, err
+	}
+
+	if len(scenarios) == 0 {
+		return "", 0, fmt.Errorf("feature %v does not contain scenarios", feature.Path)
+	}
+
+	// the tags of the scenarios include the tags of the feature
+	featureLevel, err := level.Of(scenarios[0])
+	if err != nil {
+		return "", 0, err
+	}
+
+	excluded := 0
+	for _, tags := range scenarios {
+		if !conformance.MatchTags(godogTags, tags) {
+			excluded++
+		}
+	}
+
+	return featureLevel, excluded, nil
+}
+
+// isUnsupported checks if the profile excludes all the scenarios of a feature.
+// The feature is recorded to include the scenarios excluded by the profile in
+// the summary (the feature, or some of its scenarios, are not executed).
+func isUnsupported(feature conformance.Feature) (bool, error) {
+	if profile == nil {
+		return false, nil
+	}
+
+	featurePath := filepath.Join(featuresPath, filepath.Base(feature.Path))
+
+	unsupported, err := profile.IsUnsupported(featurePath)
+	if err != nil {
+		return false, err
+	}
+
+	profiledFeaturesMu.Lock()
+	profiledFeatures = append(profiledFeatures, featurePath)
+	profiledFeaturesMu.Unlock()
+
+	return unsupported, nil
+}
+
+// runFeature runs the scenarios defined in a feature file. Each invocation
+// uses a new godog suite, isolating the state of the scenarios between features.
+// When features run concurrently, the output is written once the feature ends.
+// With a concurrency greater than one, each scenario runs in a different suite.
+func runFeature(feature conformance.Feature, concurrency int) (int, error) {
+	featurePath := filepath.Join(featuresPath, filepath.Base(feature.Path))
+
+	if concurrency == 1 {
+		return runScenarios(feature, featurePath, fmt.Sprintf("%v-report.json", filepath.Base(feature.Path)))
+	}
+
+	scenarios, err := conformance.Scenarios(featurePath)
+	if err != nil {
+		return 0, err
+	}
+
+	var (
+		wg     sync.WaitGroup
+		mu     sync.Mutex
+		failed int
+		runErr error
+	)
+
+	// limit the number of scenarios of the feature running at the same time
+	queue := make(chan struct{}, concurrency)
+
+	// each scenario (or scenario outline) runs in a different suite
+	for _, line := range conformance.ScenarioLines(scenarios, godogTags) {
+		line := line
+
+		wg.Add(1)
+		queue <- struct{}{}
+
+		go func() {
+			defer func() {
+				<-queue
+				wg.Done()
+			}()
+
+			code, err := runScenarios(feature, fmt.Sprintf("%v:%v", featurePath, line),
+				fmt.Sprintf("%v-%v-report.json", filepath.Base(feature.Path), line))
+
+			mu.Lock()
+			defer mu.Unlock()
+
+			if code != 0 {
+				failed = code
 			}
+
+			if err != nil && runErr == nil {
+				runErr = err
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	return failed, runErr
+}
+
+// runScenarios runs the scenarios of a feature file (or a single scenario
+// using the syntax file:line) writing the cucumber report in reportName.
+// Every scenario starts with a new state.
+func runScenarios(feature conformance.Feature, featurePath, reportName string) (int, error) {
+	var output io.Writer = os.Stdout
+
+	if godogFormat == "cucumber" {
+		rf := path.Join(godogOutput, reportName)
+		file, err := os.Create(rf)
+		if err != nil {
+			return 0, fmt.Errorf("error creating report file %v: %v", rf, err)
+		}
+
+		output = file
+		defer func() {
+			_ = file.Sync()
+			_ = file.Close()
+		}()
+
+		reportFilesMu.Lock()
+		reportFiles = append(reportFiles, rf)
+		reportFilesMu.Unlock()
+	} else if godogConcurrency > 1 || scenarioConcurrency > 1 {
+		buf := &bytes.Buffer{}
+
+		output = buf
+		defer func() {
+			stdoutMu.Lock()
+			defer stdoutMu.Unlock()
+
+			_, _ = io.Copy(os.Stdout, buf)
 		}()
 	}
+
+	return godog.RunWithOptions("conformance", func(s *godog.Suite) {
+		// state shared by the common and feature steps, reset
+		// before every scenario by the common steps
+		state := tstate.New(nil)
+
+		steps.FeatureContext(s, state)
+		feature.Context(s, state)
+	}, godog.Options{
+		Format:        godogFormat,
+		Paths:         []string{featurePath},
+		Tags:          godogTags,
+		StopOnFailure: godogStopOnFailure,
+		NoColors:      godogNoColors,
+		Output:        output,
+		Concurrency:   1, // godog only runs features concurrently
+	}), nil
 }
@@ -0,0 +1,111 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"embed"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/aledbf/ingress-conformance-bdd/test/utils"
+)
+
+// The features and manifests are embedded in the test binary, so the
+// suite can run from any working directory without additional files.
+var (
+	//go:embed features/*.feature
+	embeddedFeatures embed.FS
+
+	//go:embed manifests
+	embeddedManifests embed.FS
+)
+
+// loadManifests returns the manifests located in the directory or,
+// if the directory is not specified, the manifests embedded in the binary.
+func loadManifests(dir string) (fs.FS, error) {
+	if dir == "" {
+		return fs.Sub(embeddedManifests, "manifests")
+	}
+
+	path, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	if !utils.IsDir(path) {
+		return nil, fmt.Errorf("the specified value in the flag --manifests (%v) is not a directory", dir)
+	}
+
+	return os.DirFS(path), nil
+}
+
+// loadFeatures returns the directory containing the feature files. godog
+// only reads features from the filesystem so, if the directory is not
+// specified, the features embedded in the binary are written in a temporary
+// directory. The returned function removes the temporary directory.
+func loadFeatures(dir string) (string, func(), error) {
+	if dir != "" {
+		path, err := filepath.Abs(dir)
+		if err != nil {
+			return "", nil, err
+		}
+
+		if !utils.IsDir(path) {
+			return "", nil, fmt.Errorf("the specified value in the flag --features (%v) is not a directory", dir)
+		}
+
+		return path, func() {}, nil
+	}
+
+	tmpDir, err := os.MkdirTemp("", "ingress-conformance-")
+	if err != nil {
+		return "", nil, err
+	}
+
+	cleanup := func() {
+		_ = os.RemoveAll(tmpDir)
+	}
+
+	files, err := fs.Glob(embeddedFeatures, "features/*.feature")
+	if err != nil {
+		cleanup()
+		return "", nil, err
+	}
+
+	path := filepath.Join(tmpDir, "features")
+	if err := os.Mkdir(path, 0755); err != nil {
+		cleanup()
+		return "", nil, err
+	}
+
+	for _, file := range files {
+		data, err := embeddedFeatures.ReadFile(file)
+		if err != nil {
+			cleanup()
+			return "", nil, err
+		}
+
+		if err := os.WriteFile(filepath.Join(path, filepath.Base(file)), data, 0644); err != nil {
+			cleanup()
+			return "", nil, err
+		}
+	}
+
+	return path, cleanup, nil
+}
@@ -1,4 +1,4 @@
-        @sig-network @conformance @release-1.19
+        @sig-network @conformance @core @release-1.19
 Feature: Default backend
   An Ingress with no rules sends all traffic to a single default backend.
   The default backend is typically a configuration option of the
@@ -20,18 +20,18 @@ Feature: Default backend
             Given a new random namespace
               And reading Ingress from manifest "scenarios/002/ing.yaml"
               And creating Ingress from manifest
-             When The ingress status shows the IP address or FQDN where is exposed
-              And Header "Host" with value "foo.bar"
-              And Send HTTP request with method "GET"
-             Then Response status code is 404
+             When the ingress status shows the IP address or FQDN where is exposed
+              And the request header "Host" with value "foo.bar"
+              And send HTTP request with method "GET"
+             Then the response status code is 404
 
         Scenario: Ingress should return 404 for paths with an invalid backend serviceName
             Given a new random namespace
               And reading Ingress from manifest "scenarios/003/ing.yaml"
               And creating Ingress from manifest
-             When The ingress status shows the IP address or FQDN where is exposed
-              And Header "Host" with value "foo.bar"
-             Then Send HTTP request with <path> and <method> checking response status code is 404:
+             When the ingress status shows the IP address or FQDN where is exposed
+              And the request header "Host" with value "foo.bar"
+             Then send HTTP request with <path> and <method> checking response status code is 404:
                   |  path   | method  |
                   | /test   | GET     |
                   | /       | POST    |
@@ -42,8 +42,8 @@ Feature: Default backend
         Scenario: Ingress with valid host and path /test should return 404 for unmapped path "/"
             Given a new random namespace
               And creating objects from directory "scenarios/004"
-             When The ingress status shows the IP address or FQDN where is exposed
-              And With path "/"
-              And Header "Host" with value "foo.bar"
-              And Send HTTP request with method "GET"
-             Then Response status code is 404
+             When the ingress status shows the IP address or FQDN where is exposed
+              And the request path "/"
+              And the request header "Host" with value "foo.bar"
+              And send HTTP request with method "GET"
+             Then the response status code is 404
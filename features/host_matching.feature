@@ -0,0 +1,119 @@
+        @sig-network @conformance @core @release-1.19 @host-matching
+Feature: Host matching
+  An Ingress rule can restrict the requests it matches to a host.
+  Hosts can be precise matches (for example "foo.bar.com") or a
+  wildcard (for example "*.foo.com"). Precise matches require that
+  the HTTP host header matches the host field. Wildcard matches require
+  the HTTP host header is equal to the suffix of the wildcard rule,
+  where the wildcard covers a single DNS label.
+
+  Host names are case insensitive and the port in the host header,
+  if present, is not used to match the rule.
+
+    Rules:
+    - Requests are sent to the backend of the rule matching the host header.
+    - Rules of different Ingresses using the same host are merged.
+    - A precise host takes precedence over a wildcard host.
+    - Requests not matching any host are sent to the default backend (status code 404).
+
+        Scenario: An Ingress with multiple hosts sends requests to the backend of the matching host
+            Given a new random namespace
+              And creating echo backends:
+                  | name |
+                  | foo  |
+                  | bar  |
+              And reading Ingress from manifest "scenarios/015/ing.yaml"
+              And creating Ingress from manifest
+             When the ingress status shows the IP address or FQDN where is exposed
+             Then send HTTP request with <host>, <path> and <method> checking the <status> and <backend>:
+                  | host                  | path | method | status | backend |
+                  | foo.host-matching     | /    | GET    | 200    | foo     |
+                  | bar.host-matching     | /    | GET    | 200    | bar     |
+                  | bar.host-matching     | /baz | POST   | 200    | bar     |
+                  | unknown.host-matching | /    | GET    | 404    | -       |
+
+        Scenario: Host matching is case insensitive
+            Given a new random namespace
+              And creating echo backends:
+                  | name |
+                  | foo  |
+                  | bar  |
+              And reading Ingress from manifest "scenarios/015/ing.yaml"
+              And creating Ingress from manifest
+             When the ingress status shows the IP address or FQDN where is exposed
+             Then send HTTP request with <host>, <path> and <method> checking the <status> and <backend>:
+                  | host              | path | method | status | backend |
+                  | FOO.host-matching | /    | GET    | 200    | foo     |
+                  | Bar.Host-Matching | /    | GET    | 200    | bar     |
+
+        Scenario: The port in the host header is ignored
+            Given a new random namespace
+              And creating echo backends:
+                  | name |
+                  | foo  |
+                  | bar  |
+              And reading Ingress from manifest "scenarios/015/ing.yaml"
+              And creating Ingress from manifest
+             When the ingress status shows the IP address or FQDN where is exposed
+             Then send HTTP request with <host>, <path> and <method> checking the <status> and <backend>:
+                  | host                     | path | method | status | backend |
+                  | foo.host-matching:80     | /    | GET    | 200    | foo     |
+                  | bar.host-matching:80     | /    | GET    | 200    | bar     |
+                  | unknown.host-matching:80 | /    | GET    | 404    | -       |
+
+        Scenario: Ingresses using the same host are merged
+            Given a new random namespace
+              And creating echo backends:
+                  | name |
+                  | foo  |
+                  | bar  |
+              And reading Ingress from manifest "scenarios/016/ing-foo.yaml"
+              And creating Ingress from manifest
+              And reading Ingress from manifest "scenarios/016/ing-bar.yaml"
+              And creating Ingress from manifest
+             When the ingress status shows the IP address or FQDN where is exposed
+             Then send HTTP request with <host>, <path> and <method> checking the <status> and <backend>:
+                  | host                 | path | method | status | backend |
+                  | shared.host-matching | /foo | GET    | 200    | foo     |
+                  | shared.host-matching | /bar | GET    | 200    | bar     |
+                  | shared.host-matching | /baz | GET    | 404    | -       |
+
+        @wildcard-host
+        Scenario: A wildcard host matches a single DNS label
+            Given a new random namespace
+              And creating echo backends:
+                  | name     |
+                  | foo      |
+                  | wildcard |
+              And reading Ingress from manifest "scenarios/017/ing.yaml"
+              And creating Ingress from manifest
+             When the ingress status shows the IP address or FQDN where is exposed
+             Then send HTTP request with <host>, <path> and <method> checking the <status> and <backend>:
+                  | host                           | path | method | status | backend  |
+                  | bar.wildcard.host-matching     | /    | GET    | 200    | wildcard |
+                  | BAZ.wildcard.host-matching     | /    | GET    | 200    | wildcard |
+                  | foo.wildcard.host-matching     | /    | GET    | 200    | foo      |
+                  | bar.foo.wildcard.host-matching | /    | GET    | 404    | -        |
+                  | wildcard.host-matching         | /    | GET    | 404    | -        |
+
+        Scenario Outline: The same manifest serves requests for different hosts
+            Given a new random namespace
+              And creating echo backends:
+                  | name      |
+                  | <backend> |
+              And the manifest values:
+                  | name    | value     |
+                  | Host    | <host>    |
+                  | Backend | <backend> |
+              And creating objects from directory "scenarios/018"
+             When the ingress status shows the IP address or FQDN where is exposed
+              And the request header "Host" with value "<host>"
+              And send HTTP request with method "GET"
+             Then the response status code is 200
+              And the backend received host "<host>"
+
+            Examples:
+                  | host                       | backend |
+                  | template.host-matching     | foo     |
+                  | bar.template.host-matching | bar     |
+                  | foo.template.host-matching | foo     |
@@ -0,0 +1,30 @@
+        @sig-network @conformance @extended @release-1.19 @ingress-class @serial
+Feature: Ingress class
+  An IngressClass identifies the controller responsible for implementing
+  an Ingress. Ingresses reference a class using the field spec.ingressClassName
+  and a controller must ignore Ingresses referencing a class it does not own.
+
+  An IngressClass can be marked as default for the cluster using the annotation
+  ingressclass.kubernetes.io/is-default-class. Ingresses created without a class
+  are assigned the default IngressClass.
+
+    Rules:
+    - Ingresses referencing a class handled by a different controller do not acquire an address.
+    - Ingresses without class are assigned the default IngressClass.
+
+        Scenario: Ingress referencing a class not owned by the controller is ignored
+            Given a new random namespace
+              And a new IngressClass with controller "example.com/ingress-conformance-not-existing"
+              And reading Ingress from manifest "scenarios/006/ing.yaml"
+              And the Ingress references the new IngressClass
+              And creating Ingress from manifest
+             Then the ingress status does not show an address after 30 seconds
+
+        Scenario: Ingress without class uses the default IngressClass
+            Given a new random namespace
+              And the IngressClass used by the suite is the default
+              And reading Ingress from manifest "scenarios/006/ing.yaml"
+              And the Ingress does not reference a class
+              And creating Ingress from manifest
+             Then the Ingress is assigned the IngressClass used by the suite
+              And the ingress status shows the IP address or FQDN where is exposed
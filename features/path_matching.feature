@@ -0,0 +1,122 @@
+        @sig-network @conformance @core @release-1.19 @path-matching
+Feature: Path matching
+  Each path in an Ingress rule has a corresponding path type.
+  Exact matches the URL path exactly and with case sensitivity.
+  Prefix matches based on a URL path prefix split by /. Matching is done
+  on a path element by element basis and is case sensitive. A trailing
+  slash in the path of the rule is ignored.
+  ImplementationSpecific matching is up to the IngressClass.
+
+  If two or more paths in an Ingress match a request, the longest
+  matching path takes precedence. If two paths are equal, an Exact
+  path type is preferred over a Prefix path type.
+
+    Rules:
+    - Requests not matching any path are sent to the default backend (status code 404).
+    - The longest matching path is used to select the backend.
+    - For paths of the same length, Exact is preferred over Prefix.
+
+        Scenario: An Ingress with an Exact path only matches the exact path
+            Given a new random namespace
+              And creating echo backends:
+                  | name |
+                  | foo  |
+              And reading Ingress from manifest "scenarios/009/ing.yaml"
+              And creating Ingress from manifest
+             When the ingress status shows the IP address or FQDN where is exposed
+              And the request header "Host" with value "exact.path-matching"
+             Then send HTTP request with <path> and <method> checking the <status> and <backend>:
+                  | path     | method | status | backend |
+                  | /foo     | GET    | 200    | foo     |
+                  | /foo     | POST   | 200    | foo     |
+                  | /foo/    | GET    | 404    | -       |
+                  | /foobar  | GET    | 404    | -       |
+                  | /foo/bar | GET    | 404    | -       |
+                  | /FOO     | GET    | 404    | -       |
+                  | /        | GET    | 404    | -       |
+
+        Scenario: An Ingress with a Prefix path matches path elements
+            Given a new random namespace
+              And creating echo backends:
+                  | name |
+                  | foo  |
+              And reading Ingress from manifest "scenarios/010/ing.yaml"
+              And creating Ingress from manifest
+             When the ingress status shows the IP address or FQDN where is exposed
+              And the request header "Host" with value "prefix.path-matching"
+             Then send HTTP request with <path> and <method> checking the <status> and <backend>:
+                  | path         | method | status | backend |
+                  | /foo         | GET    | 200    | foo     |
+                  | /foo/        | GET    | 200    | foo     |
+                  | /foo/bar     | GET    | 200    | foo     |
+                  | /foo/bar/baz | DELETE | 200    | foo     |
+                  | /foobar      | GET    | 404    | -       |
+                  | /FOO         | GET    | 404    | -       |
+                  | /            | GET    | 404    | -       |
+
+        Scenario: A trailing slash in a Prefix path is ignored
+            Given a new random namespace
+              And creating echo backends:
+                  | name |
+                  | foo  |
+              And reading Ingress from manifest "scenarios/011/ing.yaml"
+              And creating Ingress from manifest
+             When the ingress status shows the IP address or FQDN where is exposed
+              And the request header "Host" with value "trailing-slash.path-matching"
+             Then send HTTP request with <path> and <method> checking the <status> and <backend>:
+                  | path     | method | status | backend |
+                  | /foo     | GET    | 200    | foo     |
+                  | /foo/    | GET    | 200    | foo     |
+                  | /foo/bar | GET    | 200    | foo     |
+                  | /foobar  | GET    | 404    | -       |
+
+        Scenario: The longest matching path takes precedence
+            Given a new random namespace
+              And creating echo backends:
+                  | name   |
+                  | root   |
+                  | foo    |
+                  | foobar |
+              And reading Ingress from manifest "scenarios/012/ing.yaml"
+              And creating Ingress from manifest
+             When the ingress status shows the IP address or FQDN where is exposed
+              And the request header "Host" with value "longest-match.path-matching"
+             Then send HTTP request with <path> and <method> checking the <status> and <backend>:
+                  | path         | method | status | backend |
+                  | /            | GET    | 200    | root    |
+                  | /bar         | GET    | 200    | root    |
+                  | /foo         | GET    | 200    | foo     |
+                  | /foobar      | GET    | 200    | root    |
+                  | /foo/baz     | GET    | 200    | foo     |
+                  | /foo/bar     | GET    | 200    | foobar  |
+                  | /foo/bar/baz | GET    | 200    | foobar  |
+                  | /foo/barbaz  | GET    | 200    | foo     |
+
+        Scenario: An Exact path is preferred over a Prefix path of the same length
+            Given a new random namespace
+              And creating echo backends:
+                  | name   |
+                  | exact  |
+                  | prefix |
+              And reading Ingress from manifest "scenarios/013/ing.yaml"
+              And creating Ingress from manifest
+             When the ingress status shows the IP address or FQDN where is exposed
+              And the request header "Host" with value "exact-precedence.path-matching"
+             Then send HTTP request with <path> and <method> checking the <status> and <backend>:
+                  | path     | method | status | backend |
+                  | /foo     | GET    | 200    | exact   |
+                  | /foo/    | GET    | 200    | prefix  |
+                  | /foo/bar | GET    | 200    | prefix  |
+
+        Scenario: An Ingress with an ImplementationSpecific path matches the path
+            Given a new random namespace
+              And creating echo backends:
+                  | name |
+                  | foo  |
+              And reading Ingress from manifest "scenarios/014/ing.yaml"
+              And creating Ingress from manifest
+             When the ingress status shows the IP address or FQDN where is exposed
+              And the request header "Host" with value "implementation-specific.path-matching"
+             Then send HTTP request with <path> and <method> checking the <status> and <backend>:
+                  | path | method | status | backend |
+                  | /foo | GET    | 200    | foo     |
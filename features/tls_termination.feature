@@ -0,0 +1,35 @@
+        @sig-network @conformance @core @release-1.19 @tls
+Feature: TLS termination
+  An Ingress can secure traffic specifying a Secret of type kubernetes.io/tls
+  containing a certificate and private key. TLS is terminated at the ingress
+  point using the certificate of the hosts listed in the tls section.
+  The certificate is selected using the server name (SNI) sent by the client.
+
+    Rules:
+    - The TLS handshake for a host listed in the tls section succeeds.
+    - The certificate served is the one stored in the referenced Secret.
+    - The certificate is selected using the server name (SNI), not the Host header.
+
+        Scenario: Ingress with a tls section terminates HTTPS connections for the host
+            Given a new random namespace
+              And a new TLS certificate for host "foo.bar" stored in secret "foo-bar-tls"
+              And creating objects from directory "scenarios/007"
+             When the ingress status shows the IP address or FQDN where is exposed
+              And the request header "Host" with value "foo.bar"
+              And eventually send HTTPS request with method "GET" until the response status code is 200
+              And eventually send HTTPS request with method "GET" until the TLS certificate is valid for "foo.bar"
+             Then the TLS handshake succeeds
+              And the server certificate contains the SAN "foo.bar"
+              And the server certificate is issued by the generated CA
+              And the response status code is 200
+
+        Scenario: The certificate is selected using the server name when it differs from the Host header
+            Given a new random namespace
+              And a new TLS certificate for host "foo.bar" stored in secret "foo-bar-tls"
+              And creating objects from directory "scenarios/007"
+             When the ingress status shows the IP address or FQDN where is exposed
+              And the request header "Host" with value "unknown.foo.bar"
+              And the TLS server name is "foo.bar"
+              And eventually send HTTPS request with method "GET" until the TLS certificate is valid for "foo.bar"
+             Then the TLS handshake succeeds
+              And the server certificate contains the SAN "foo.bar"
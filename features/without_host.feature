@@ -1,4 +1,4 @@
-        @sig-network @conformance @release-1.19 @feature-without-host
+        @sig-network @conformance @core @release-1.19 @feature-without-host
 Feature: Ingress without host field
   I want to expose Services using Ingress definitions wihout using host field
 
@@ -6,6 +6,17 @@ Feature: Ingress without host field
             Given a new random namespace
               And creating objects from directory "scenarios/005"
              When the ingress status shows the IP address or FQDN where is exposed
-              And send GET HTTP request
-             Then the HTTP response code is 200
-              And Header "Host" is not present
+              And eventually send HTTP request with method "GET" until the response status code is 200
+             Then the response status code is 200
+              And the response header "Host" is not present
+
+        Scenario: Requests to an ingress without host are proxied to the backend
+            Given a new random namespace
+              And creating objects from directory "scenarios/008"
+             When the ingress status shows the IP address or FQDN where is exposed
+              And the request path "/foo"
+              And eventually send HTTP request with method "GET" until the response status code is 200
+             Then the response status code is 200
+              And the backend received path "/foo"
+              And the backend received method "GET"
+              And the backend received header "X-Forwarded-For"
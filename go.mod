@@ -1,39 +1,39 @@
 module github.com/aledbf/ingress-conformance-bdd
 
-go 1.14
+go 1.16
 
 require (
 	github.com/cucumber/gherkin-go/v11 v11.0.0
 	github.com/cucumber/godog v0.9.1-0.20200326115311-68d94c03b821
 	github.com/cucumber/messages-go/v10 v10.0.3
 	github.com/iancoleman/orderedmap v0.0.0-20190318233801-ac98e3ecb4b0
-	k8s.io/api v0.18.0
-	k8s.io/apimachinery v0.18.0
-	k8s.io/client-go v0.18.0
+	k8s.io/api v0.19.0
+	k8s.io/apimachinery v0.19.0
+	k8s.io/client-go v0.19.0
 	k8s.io/klog v1.0.0
 	k8s.io/kubectl v0.0.0
 )
 
 replace (
-	k8s.io/api => k8s.io/api v0.18.0
-	k8s.io/apiextensions-apiserver => k8s.io/apiextensions-apiserver v0.18.0
-	k8s.io/apimachinery => k8s.io/apimachinery v0.18.0
-	k8s.io/apiserver => k8s.io/apiserver v0.18.0
-	k8s.io/cli-runtime => k8s.io/cli-runtime v0.18.0
-	k8s.io/client-go => k8s.io/client-go v0.18.0
-	k8s.io/cloud-provider => k8s.io/cloud-provider v0.18.0
-	k8s.io/cluster-bootstrap => k8s.io/cluster-bootstrap v0.18.0
-	k8s.io/code-generator => k8s.io/code-generator v0.18.0
-	k8s.io/component-base => k8s.io/component-base v0.18.0
-	k8s.io/cri-api => k8s.io/cri-api v0.18.0
-	k8s.io/csi-translation-lib => k8s.io/csi-translation-lib v0.18.0
-	k8s.io/kube-aggregator => k8s.io/kube-aggregator v0.18.0
-	k8s.io/kube-controller-manager => k8s.io/kube-controller-manager v0.18.0
-	k8s.io/kube-proxy => k8s.io/kube-proxy v0.18.0
-	k8s.io/kube-scheduler => k8s.io/kube-scheduler v0.18.0
-	k8s.io/kubectl => k8s.io/kubectl v0.18.0
-	k8s.io/kubelet => k8s.io/kubelet v0.18.0
-	k8s.io/legacy-cloud-providers => k8s.io/legacy-cloud-providers v0.18.0
-	k8s.io/metrics => k8s.io/metrics v0.18.0
-	k8s.io/sample-apiserver => k8s.io/sample-apiserver v0.18.0
+	k8s.io/api => k8s.io/api v0.19.0
+	k8s.io/apiextensions-apiserver => k8s.io/apiextensions-apiserver v0.19.0
+	k8s.io/apimachinery => k8s.io/apimachinery v0.19.0
+	k8s.io/apiserver => k8s.io/apiserver v0.19.0
+	k8s.io/cli-runtime => k8s.io/cli-runtime v0.19.0
+	k8s.io/client-go => k8s.io/client-go v0.19.0
+	k8s.io/cloud-provider => k8s.io/cloud-provider v0.19.0
+	k8s.io/cluster-bootstrap => k8s.io/cluster-bootstrap v0.19.0
+	k8s.io/code-generator => k8s.io/code-generator v0.19.0
+	k8s.io/component-base => k8s.io/component-base v0.19.0
+	k8s.io/cri-api => k8s.io/cri-api v0.19.0
+	k8s.io/csi-translation-lib => k8s.io/csi-translation-lib v0.19.0
+	k8s.io/kube-aggregator => k8s.io/kube-aggregator v0.19.0
+	k8s.io/kube-controller-manager => k8s.io/kube-controller-manager v0.19.0
+	k8s.io/kube-proxy => k8s.io/kube-proxy v0.19.0
+	k8s.io/kube-scheduler => k8s.io/kube-scheduler v0.19.0
+	k8s.io/kubectl => k8s.io/kubectl v0.19.0
+	k8s.io/kubelet => k8s.io/kubelet v0.19.0
+	k8s.io/legacy-cloud-providers => k8s.io/legacy-cloud-providers v0.19.0
+	k8s.io/metrics => k8s.io/metrics v0.19.0
+	k8s.io/sample-apiserver => k8s.io/sample-apiserver v0.19.0
 )
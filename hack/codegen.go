@@ -17,6 +17,8 @@ import (
 	"path/filepath"
 	"reflect"
 	"regexp"
+	"sort"
+	"strconv"
 	"strings"
 	"unicode"
 
@@ -25,6 +27,7 @@ import (
 	"github.com/iancoleman/orderedmap"
 	"k8s.io/apimachinery/pkg/util/sets"
 
+	"github.com/aledbf/ingress-conformance-bdd/test/conformance/level"
 	"github.com/aledbf/ingress-conformance-bdd/test/utils"
 )
 
@@ -33,30 +36,38 @@ func main() {
 		update          bool
 		features        []string
 		conformancePath string
+		stepsPath       string
 	)
 
 	flag.BoolVar(&update, "update", false, "update files in place in case of missing steps or method definitions")
 	flag.StringVar(&conformancePath, "conformance-path", "test/conformance", "path to conformance test package location")
+	flag.StringVar(&stepsPath, "steps-path", "test/steps", "path to the package containing common step definitions")
 
 	flag.Parse()
 
 	// 1. verify flags
 	features = flag.CommandLine.Args()
 	if len(features) == 0 {
-		fmt.Println("Usage: codegen [-update=false] [-conformance-path=test/conformance] [features]")
+		fmt.Println("Usage: codegen [-update=false] [-conformance-path=test/conformance] [-steps-path=test/steps] [features]")
 		fmt.Println()
 		fmt.Println("Example: codegen features/default_backend.feature")
 		flag.CommandLine.Usage()
 		os.Exit(1)
 	}
 
-	// 2. parse template
+	// 2. extract steps provided by the common step library
+	commonSteps, err := extractSteps(stepsPath)
+	if err != nil {
+		log.Fatalf("Unexpected error reading common steps from %v: %v", stepsPath, err)
+	}
+
+	// 3. parse template
 	codeTmpl, err := template.New("template").Funcs(templateFuncs).Parse(goTemplate)
 	if err != nil {
 		log.Fatalf("Unexpected error parsing template: %v", err)
 	}
 
-	// 3. if features is a directory, iterate and search for files with extension .feature
+	// 4. if features is a directory, iterate and search for files with extension .feature
 	if len(features) == 1 && utils.IsDir(features[0]) {
 		root := filepath.Dir(features[0])
 		features = []string{}
@@ -67,25 +78,100 @@ func main() {
 		}
 	}
 
-	// 4. iterate feature files
+	// 5. iterate feature files
 	for _, path := range features {
-		err := processFeature(path, conformancePath, update, codeTmpl)
+		err := processFeature(path, conformancePath, commonSteps, update, codeTmpl)
 		if err != nil {
 			log.Fatal(err)
 		}
 	}
+
+	// 6. generate the registry of the features located in the same directories
+	err = processRegistry(features, conformancePath, update)
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
+// registryFile is the name of the file containing the registry of the features
+const registryFile = "features.go"
+
+// processRegistry generates the list of features in the conformance package, using
+// all the feature files located in the directories of the processed features.
+func processRegistry(features []string, conformance string, update bool) error {
+	dirs := sets.NewString()
+	for _, feature := range features {
+		dirs.Insert(filepath.Dir(feature))
+	}
+
+	var files []string
+	for _, dir := range dirs.List() {
+		matches, err := filepath.Glob(filepath.Join(dir, "*.feature"))
+		if err != nil {
+			return err
+		}
+
+		files = append(files, matches...)
+	}
+
+	sort.Strings(files)
+
+	buf := bytes.NewBuffer(make([]byte, 0))
+	buf.WriteString(registryHeader)
+
+	buf.WriteString("import (\n")
+	for _, file := range files {
+		fmt.Fprintf(buf, "\t%q\n", path.Join(modulePath, conformance, generatePackage(file)))
+	}
+	buf.WriteString(")\n\n")
+
+	buf.WriteString("// Features contains the features of the suite sorted by path\n")
+	buf.WriteString("var Features = []Feature{\n")
+	for _, file := range files {
+		fmt.Fprintf(buf, "\t{Path: %q, Context: %v.FeatureContext},\n", filepath.ToSlash(file), generatePackage(file))
+	}
+	buf.WriteString("}\n")
+
+	content, err := format.Source(buf.Bytes())
+	if err != nil {
+		return fmt.Errorf("error formatting registry: %w", err)
+	}
+
+	goFile := filepath.Join(conformance, registryFile)
+
+	current, err := ioutil.ReadFile(goFile)
+	if err == nil && bytes.Equal(current, content) {
+		return nil
+	}
+
+	if !update {
+		return fmt.Errorf("generated code is out of date (registry of features %v)", goFile)
+	}
+
+	log.Printf("Updating registry of features %v...", goFile)
+
+	return ioutil.WriteFile(goFile, content, 0644)
 }
 
-func processFeature(path, conformance string, update bool, template *template.Template) error {
-	// 5. parse feature file
-	featureSteps, err := parseFeature(path)
+// modulePath is the go module of the repository
+const modulePath = "github.com/aledbf/ingress-conformance-bdd"
+
+const registryHeader = `// Code generated by hack/codegen.go. DO NOT EDIT.
+
+package conformance
+
+`
+
+func processFeature(path, conformance string, commonSteps []*regexp.Regexp, update bool, template *template.Template) error {
+	// 6. parse feature file (ignoring steps provided by the common step library)
+	featureSteps, err := parseFeature(path, commonSteps)
 	if err != nil {
 		return fmt.Errorf("parsing feature file: %w", err)
 	}
 
-	// 6. generate package name to use
+	// 7. generate package name to use
 	packageName := generatePackage(path)
-	// 7. check if go source file exists
+	// 8. check if go source file exists
 	goFile := filepath.Join(conformance, packageName, "feature.go")
 	isGoFileOk := utils.Exists(goFile)
 
@@ -97,7 +183,7 @@ func processFeature(path, conformance string, update bool, template *template.Te
 		GoFile:       goFile,
 	}
 
-	// 8. Extract functions from go source code
+	// 9. Extract functions from go source code
 	if isGoFileOk {
 		goFunctions, err := extractFuncs(goFile)
 		if err != nil {
@@ -107,7 +193,7 @@ func processFeature(path, conformance string, update bool, template *template.Te
 		mapping.GoDefinitions = goFunctions
 	}
 
-	// 9. check if feature file is in sync with go code
+	// 10. check if feature file is in sync with go code
 	isInSync := false
 
 	signatureChanges := []SignatureChange{}
@@ -184,7 +270,7 @@ func processFeature(path, conformance string, update bool, template *template.Te
 		}
 	}
 
-	// 10. check signatures are ok
+	// 11. check signatures are ok
 	if len(signatureChanges) != 0 {
 		var argBuf bytes.Buffer
 		for _, sc := range signatureChanges {
@@ -198,12 +284,12 @@ function %v
 		return fmt.Errorf("source file %v has a different signature/s:\n %v", mapping.GoFile, argBuf.String())
 	}
 
-	// 11. if in sync, nothing to do
+	// 12. if in sync, nothing to do
 	if isInSync {
 		return nil
 	}
 
-	// 12. New go feature file
+	// 13. New go feature file
 	if !isGoFileOk {
 		if !update {
 			return fmt.Errorf("generated code is out of date (from %v feature, new go file %v)",
@@ -218,7 +304,7 @@ function %v
 			return err
 		}
 
-		// 10. if update is set
+		// 11. if update is set
 		if update {
 			isDirOk := utils.IsDir(mapping.GoFile)
 			if !isDirOk {
@@ -233,9 +319,6 @@ function %v
 				return err
 			}
 
-			featFile := filepath.Base(path)
-			log.Printf(`Please add '"features/%v": %v.FeatureContext' to features map defined in conformance_test.go (order matters)`,
-				featFile, mapping.Package)
 		}
 
 		return nil
@@ -245,7 +328,7 @@ function %v
 		return nil
 	}
 
-	// 13. if update is set
+	// 14. if update is set
 	if update {
 		log.Printf("Updating go file %v...", mapping.GoFile)
 		err := updateGoTestFile(mapping.GoFile, mapping.NewFunctions)
@@ -306,39 +389,39 @@ const goTemplate = `package {{ .Package }}
 
 import (
 	"github.com/cucumber/godog"
-	"github.com/cucumber/messages-go/v10"
 
 	tstate "github.com/aledbf/ingress-conformance-bdd/test/state"
-	"github.com/aledbf/ingress-conformance-bdd/test/utils"
 )
-
-var (
+{{ if .NewFunctions }}
+// feature holds the state of a running scenario. The state is
+// shared with the common steps and replaced before every scenario.
+type feature struct {
+	// holds state of the scenarario
 	state *tstate.Scenario
-)
+}
 
 {{ range .NewFunctions }}
-func {{ .Name }}{{ argsFromMap .Args false }} error {
+func (f *feature) {{ .Name }}{{ argsFromMap .Args false }} error {
 	return godog.ErrPending
 }
 {{end}}
 
-func FeatureContext(s *godog.Suite) { {{ range .NewFunctions }}
-	s.Step({{ backticked .Expr | unescape }}, {{ .Name }}){{end}}
-
-	s.BeforeScenario(func(this *messages.Pickle) {
-		state = tstate.New(nil)
-	})
-
-	s.AfterScenario(func(*messages.Pickle, error) {
-		// delete namespace an all the content
-		_ = utils.DeleteKubeNamespace(utils.KubeClient, state.Namespace)
-	})
+// FeatureContext registers the steps of the feature. Steps
+// defined in the package test/steps are registered by TestSuite.
+func FeatureContext(s *godog.Suite, state *tstate.Scenario) {
+	f := &feature{state: state}
+{{ range .NewFunctions }}
+	s.Step({{ backticked .Expr | unescape }}, f.{{ .Name }}){{end}}
 }
-`
+{{ else }}
+// FeatureContext registers the steps of the feature. All the steps
+// are provided by the package test/steps, registered by TestSuite.
+func FeatureContext(s *godog.Suite, state *tstate.Scenario) {}
+{{ end }}`
 
 // parseFeature parses a godog feature file returning the unique
-// steps definitions
-func parseFeature(path string) ([]Function, error) {
+// steps definitions not matched by one of the common steps
+func parseFeature(path string, commonSteps []*regexp.Regexp) ([]Function, error) {
 	data, err := utils.Read(path)
 	if err != nil {
 		return nil, err
@@ -351,14 +434,130 @@ func parseFeature(path string) ([]Function, error) {
 
 	scenarios := gherkin.Pickles(*gd, path, (&messages.Incrementing{}).NewId)
 
+	if err := checkLevel(gd.Feature, scenarios); err != nil {
+		return nil, fmt.Errorf("feature %v: %w", path, err)
+	}
+
 	def := []Function{}
 	for _, s := range scenarios {
-		def = parseSteps(s.Steps, def)
+		def = parseSteps(filterSteps(s.Steps, commonSteps), def)
 	}
 
 	return def, nil
 }
 
+// checkLevel verifies the feature declares exactly one conformance level
+// (i.e. @core) and the scenarios do not declare a different level
+func checkLevel(feature *messages.GherkinDocument_Feature, scenarios []*messages.Pickle) error {
+	if feature == nil {
+		return fmt.Errorf("feature definition not found")
+	}
+
+	if _, err := level.Of(tagNames(feature.Tags)); err != nil {
+		return err
+	}
+
+	for _, scenario := range scenarios {
+		var tags []string
+		for _, tag := range scenario.Tags {
+			tags = append(tags, tag.Name)
+		}
+
+		if _, err := level.Of(tags); err != nil {
+			return fmt.Errorf("scenario %q: %w (the level is declared in the feature)", scenario.Name, err)
+		}
+	}
+
+	return nil
+}
+
+func tagNames(tags []*messages.GherkinDocument_Feature_Tag) []string {
+	var names []string
+	for _, tag := range tags {
+		names = append(names, tag.Name)
+	}
+
+	return names
+}
+
+// filterSteps removes the steps matched by one of the common steps
+func filterSteps(steps []*messages.Pickle_PickleStep, commonSteps []*regexp.Regexp) []*messages.Pickle_PickleStep {
+	var filtered []*messages.Pickle_PickleStep
+
+StepsLoop:
+	for _, step := range steps {
+		for _, expr := range commonSteps {
+			if expr.MatchString(step.Text) {
+				continue StepsLoop
+			}
+		}
+
+		filtered = append(filtered, step)
+	}
+
+	return filtered
+}
+
+// extractSteps reads the go files located in a directory and returns
+// the regular expressions used in godog step definitions (s.Step calls).
+func extractSteps(dirPath string) ([]*regexp.Regexp, error) {
+	if !utils.IsDir(dirPath) {
+		return nil, fmt.Errorf("%v is not a directory", dirPath)
+	}
+
+	fset := token.NewFileSet()
+	pkgs, err := parser.ParseDir(fset, dirPath, nil, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		steps    []*regexp.Regexp
+		stepsErr error
+	)
+
+	for _, pkg := range pkgs {
+		ast.Inspect(pkg, func(n ast.Node) bool {
+			call, ok := n.(*ast.CallExpr)
+			if !ok || len(call.Args) == 0 {
+				return true
+			}
+
+			sel, ok := call.Fun.(*ast.SelectorExpr)
+			if !ok || sel.Sel.Name != "Step" {
+				return true
+			}
+
+			lit, ok := call.Args[0].(*ast.BasicLit)
+			if !ok || lit.Kind != token.STRING {
+				return true
+			}
+
+			expr, err := strconv.Unquote(lit.Value)
+			if err != nil {
+				stepsErr = err
+				return false
+			}
+
+			re, err := regexp.Compile(expr)
+			if err != nil {
+				stepsErr = fmt.Errorf("invalid step expression %v: %w", expr, err)
+				return false
+			}
+
+			steps = append(steps, re)
+
+			return true
+		})
+	}
+
+	if stepsErr != nil {
+		return nil, stepsErr
+	}
+
+	return steps, nil
+}
+
 // extractFuncs reads a file containing go source code and returns
 // the functions defined in the file.
 func extractFuncs(filePath string) ([]Function, error) {
@@ -459,7 +658,23 @@ func updateGoTestFile(filePath string, newFuncs []Function) error {
 		return err
 	}
 
-	featureFunc.Body.List = append(astSteps, featureFunc.Body.List...)
+	// steps are methods of the feature instance created
+	// in the first statement of FeatureContext
+	body := featureFunc.Body.List
+	if len(body) == 0 || !isFeatureInstance(body[0]) {
+		featureDecls, featureStmt, err := toFeatureInstance()
+		if err != nil {
+			return err
+		}
+
+		if !hasFeatureType(node) {
+			node.Decls = append(node.Decls, featureDecls...)
+		}
+
+		body = append([]ast.Stmt{featureStmt}, body...)
+	}
+
+	featureFunc.Body.List = append([]ast.Stmt{body[0]}, append(astSteps, body[1:]...)...)
 
 	var buffer bytes.Buffer
 	if err = format.Node(&buffer, fileSet, node); err != nil {
@@ -474,11 +689,65 @@ func updateGoTestFile(filePath string, newFuncs []Function) error {
 	return ioutil.WriteFile(filePath, buffer.Bytes(), fileInfo.Mode())
 }
 
+// isFeatureInstance checks if the statement is the
+// definition of the feature instance (f := &feature{})
+func isFeatureInstance(stmt ast.Stmt) bool {
+	assign, ok := stmt.(*ast.AssignStmt)
+	if !ok || assign.Tok != token.DEFINE || len(assign.Lhs) != 1 {
+		return false
+	}
+
+	ident, ok := assign.Lhs[0].(*ast.Ident)
+
+	return ok && ident.Name == "f"
+}
+
+// hasFeatureType checks if the file contains the definition of the type feature
+func hasFeatureType(node *ast.File) bool {
+	for _, decl := range node.Decls {
+		gen, ok := decl.(*ast.GenDecl)
+		if !ok || gen.Tok != token.TYPE {
+			continue
+		}
+
+		for _, spec := range gen.Specs {
+			if ts, ok := spec.(*ast.TypeSpec); ok && ts.Name.Name == "feature" {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// toFeatureInstance returns the definition of the type feature and
+// the statement that creates the instance used in FeatureContext
+func toFeatureInstance() ([]ast.Decl, ast.Stmt, error) {
+	astFeatureTpl := `
+package codegen
+type feature struct {
+	state *tstate.Scenario
+}
+
+func FeatureContext() {
+	f := &feature{state: state}
+}
+`
+	astFile, err := astFromTemplate(astFeatureTpl, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	f := astFile.Decls[1].(*ast.FuncDecl)
+
+	return astFile.Decls[:1], f.Body.List[0], nil
+}
+
 func toContextStepsfuncs(funcs []Function) ([]ast.Stmt, error) {
 	astStepsTpl := `
 package codegen
 func FeatureContext() { {{ range . }}
-	s.Step({{ backticked .Expr | unescape }}, {{ .Name }}){{end}}
+	s.Step({{ backticked .Expr | unescape }}, f.{{ .Name }}){{end}}
 }
 `
 	astFile, err := astFromTemplate(astStepsTpl, funcs)
@@ -494,7 +763,7 @@ func FeatureContext() { {{ range . }}
 func toAstFunctions(funcs []Function) ([]ast.Decl, error) {
 	astFuncTpl := `
 package codegen
-{{ range . }}func {{ .Name }}{{ argsFromMap .Args false }} error {
+{{ range . }}func (f *feature) {{ .Name }}{{ argsFromMap .Args false }} error {
 	return godog.ErrPending
 }
 
@@ -0,0 +1,147 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"io/ioutil"
+	"log"
+	"text/template"
+
+	rbacv1 "k8s.io/api/rbac/v1"
+
+	"github.com/aledbf/ingress-conformance-bdd/test/utils"
+)
+
+// generates the manifest with the RBAC required to run the conformance
+// suite in a pod, using the objects created by cmd/ingress-conformance
+func main() {
+	var (
+		output string
+		verify bool
+	)
+
+	flag.StringVar(&output, "output", "images/conformance/conformance.yaml", "path of the generated manifest")
+	flag.BoolVar(&verify, "verify", false, "verify the manifest is in sync with the rules instead of updating it")
+
+	flag.Parse()
+
+	manifest, err := render(utils.ConformanceNamespace)
+	if err != nil {
+		log.Fatalf("Unexpected error rendering template: %v", err)
+	}
+
+	if verify {
+		current, err := ioutil.ReadFile(output)
+		if err != nil {
+			log.Fatalf("Unexpected error reading %v: %v", output, err)
+		}
+
+		if !bytes.Equal(current, manifest) {
+			log.Fatalf("%v is not in sync with utils.ConformancePolicyRules (run make rbac)", output)
+		}
+
+		return
+	}
+
+	if err := ioutil.WriteFile(output, manifest, 0644); err != nil {
+		log.Fatalf("Unexpected error writing %v: %v", output, err)
+	}
+}
+
+// manifest contains the objects of the generated manifest
+type manifest struct {
+	Namespace          string
+	Labels             map[string]string
+	ServiceAccount     string
+	ClusterRole        *rbacv1.ClusterRole
+	ClusterRoleBinding *rbacv1.ClusterRoleBinding
+}
+
+// render returns the manifest with the RBAC required to run the suite in a namespace
+func render(namespace string) ([]byte, error) {
+	buf := &bytes.Buffer{}
+	err := manifestTemplate.Execute(buf, manifest{
+		Namespace:          namespace,
+		Labels:             utils.ConformanceLabels,
+		ServiceAccount:     utils.ConformanceRBACName,
+		ClusterRole:        utils.ConformanceClusterRole(),
+		ClusterRoleBinding: utils.ConformanceClusterRoleBinding(namespace),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+var manifestTemplate = template.Must(template.New("manifest").Parse(`# Code generated by hack/rbac from utils.ConformancePolicyRules. DO NOT EDIT.
+
+apiVersion: v1
+kind: Namespace
+metadata:
+  labels:
+{{- range $key, $value := .Labels }}
+    {{ $key }}: {{ $value }}
+{{- end }}
+  name: {{ .Namespace }}
+
+---
+
+apiVersion: v1
+kind: ServiceAccount
+metadata:
+  labels:
+{{- range $key, $value := .Labels }}
+    {{ $key }}: {{ $value }}
+{{- end }}
+  name: {{ .ServiceAccount }}
+  namespace: {{ .Namespace }}
+
+---
+{{ with .ClusterRoleBinding }}
+apiVersion: rbac.authorization.k8s.io/v1
+kind: ClusterRoleBinding
+metadata:
+  labels:
+{{- range $key, $value := .Labels }}
+    {{ $key }}: {{ $value }}
+{{- end }}
+  name: {{ .Name }}
+roleRef:
+  apiGroup: {{ .RoleRef.APIGroup }}
+  kind: {{ .RoleRef.Kind }}
+  name: {{ .RoleRef.Name }}
+subjects:
+{{- range .Subjects }}
+- kind: {{ .Kind }}
+  name: {{ .Name }}
+  namespace: {{ .Namespace }}
+{{- end }}
+{{ end }}
+---
+{{ with .ClusterRole }}
+apiVersion: rbac.authorization.k8s.io/v1
+kind: ClusterRole
+metadata:
+  labels:
+{{- range $key, $value := .Labels }}
+    {{ $key }}: {{ $value }}
+{{- end }}
+  name: {{ .Name }}
+rules:
+{{- range .Rules }}
+- apiGroups:
+{{- range .APIGroups }}
+  - '{{ . }}'
+{{- end }}
+  resources:
+{{- range .Resources }}
+  - {{ . }}
+{{- end }}
+  verbs:
+{{- range .Verbs }}
+  - {{ . }}
+{{- end }}
+{{- end }}
+{{- end }}
+`))
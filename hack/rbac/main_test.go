@@ -0,0 +1,130 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"io/ioutil"
+	"reflect"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	utilyaml "k8s.io/apimachinery/pkg/util/yaml"
+	"k8s.io/client-go/kubernetes/scheme"
+
+	"github.com/aledbf/ingress-conformance-bdd/test/utils"
+)
+
+func decodeManifest(t *testing.T, manifest []byte) []runtime.Object {
+	var objects []runtime.Object
+
+	reader := utilyaml.NewYAMLReader(bufio.NewReader(bytes.NewReader(manifest)))
+	for {
+		doc, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+
+		if err != nil {
+			t.Fatalf("unexpected error reading manifest: %v", err)
+		}
+
+		if len(bytes.TrimSpace(doc)) == 0 {
+			continue
+		}
+
+		obj, _, err := scheme.Codecs.UniversalDeserializer().Decode(doc, nil, nil)
+		if err != nil {
+			t.Fatalf("unexpected error decoding %s: %v", doc, err)
+		}
+
+		objects = append(objects, obj)
+	}
+
+	return objects
+}
+
+func TestRender(t *testing.T) {
+	namespace := "conformance-test"
+
+	manifest, err := render(namespace)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	objects := decodeManifest(t, manifest)
+	if len(objects) != 4 {
+		t.Fatalf("expected 4 objects but got %v", len(objects))
+	}
+
+	for _, obj := range objects {
+		switch o := obj.(type) {
+		case *corev1.Namespace:
+			if o.Name != namespace {
+				t.Errorf("expected namespace %v but got %v", namespace, o.Name)
+			}
+		case *corev1.ServiceAccount:
+			if o.Name != utils.ConformanceRBACName || o.Namespace != namespace {
+				t.Errorf("expected service account %v/%v but got %v/%v", namespace, utils.ConformanceRBACName, o.Namespace, o.Name)
+			}
+		case *rbacv1.ClusterRole:
+			expected := utils.ConformanceClusterRole()
+			if o.Name != expected.Name || !reflect.DeepEqual(o.Labels, expected.Labels) {
+				t.Errorf("expected cluster role %v %v but got %v %v", expected.Name, expected.Labels, o.Name, o.Labels)
+			}
+
+			if !reflect.DeepEqual(o.Rules, utils.ConformancePolicyRules) {
+				t.Errorf("expected rules %v but got %v", utils.ConformancePolicyRules, o.Rules)
+			}
+		case *rbacv1.ClusterRoleBinding:
+			expected := utils.ConformanceClusterRoleBinding(namespace)
+			if o.Name != expected.Name || !reflect.DeepEqual(o.Labels, expected.Labels) {
+				t.Errorf("expected cluster role binding %v %v but got %v %v", expected.Name, expected.Labels, o.Name, o.Labels)
+			}
+
+			if !reflect.DeepEqual(o.RoleRef, expected.RoleRef) {
+				t.Errorf("expected role %v but got %v", expected.RoleRef, o.RoleRef)
+			}
+
+			if !reflect.DeepEqual(o.Subjects, expected.Subjects) {
+				t.Errorf("expected subjects %v but got %v", expected.Subjects, o.Subjects)
+			}
+		default:
+			t.Errorf("unexpected object %T", obj)
+		}
+	}
+}
+
+func TestManifestInSync(t *testing.T) {
+	manifest, err := render(utils.ConformanceNamespace)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	current, err := ioutil.ReadFile("../../images/conformance/conformance.yaml")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !bytes.Equal(current, manifest) {
+		t.Errorf("images/conformance/conformance.yaml is not in sync with utils.ConformancePolicyRules (run make rbac)")
+	}
+}
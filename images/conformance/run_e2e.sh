@@ -18,31 +18,16 @@ set -o errexit
 set -o nounset
 set -o pipefail
 
-# Shutdown the tests gracefully then save the results
-shutdown () {
-    E2E_SUITE_PID=$(pgrep ingress-conformance-bdd.test)
-    echo "sending TERM to ${E2E_SUITE_PID}"
-    kill -s TERM "${E2E_SUITE_PID}"
-
-    # Kind of a hack to wait for this pid to finish.
-    # Since it's not a child of this shell we cannot use wait.
-    tail --pid "${E2E_SUITE_PID}" -f /dev/null
-    saveResults
-}
-
-saveResults() {
-    cd "${RESULTS_DIR}" || exit
-    tar -czf e2e.tar.gz ./*
-    # mark the done file as a termination notice.
-    echo -n "${RESULTS_DIR}/e2e.tar.gz" > "${RESULTS_DIR}/done"
-}
-
-# We get the TERM from kubernetes and handle it gracefully
-trap shutdown TERM
+# The features and manifests are embedded in the test binary.
+# The test binary writes the cucumber reports, summary.json and junit.xml
+# in RESULTS_DIR. When the suite finishes (or receives a TERM signal) the
+# content of the directory is archived in e2e.tar.gz and the file done,
+# containing the path of the archive, is created (sonobuoy plugin contract).
+#
+# The arguments of the script are passed to the test binary without
+# modifications (i.e. --use-ingress-class --tags "@core && ~@tls").
 
 set -x
-/ingress-conformance-bdd.test --manifests=/manifests # -format cucumber "${RESULTS_DIR}"/ingress-conformance.json
-ret=$?
-set -x
-#saveResults
-exit ${ret}
+exec /ingress-conformance-bdd.test \
+  --results-directory="${RESULTS_DIR}" \
+  "$@"
@@ -0,0 +1,60 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// echoserver replies to any HTTP request with a JSON document
+// describing the request received (see package test/echo).
+package main
+
+import (
+	"flag"
+	"log"
+	"net/http"
+	"os"
+
+	"github.com/aledbf/ingress-conformance-bdd/test/echo"
+)
+
+func main() {
+	var (
+		httpAddress  string
+		httpsAddress string
+		certFile     string
+		keyFile      string
+	)
+
+	flag.StringVar(&httpAddress, "http-address", ":8080", "Address to listen for HTTP requests")
+	flag.StringVar(&httpsAddress, "https-address", ":8443", "Address to listen for HTTPS requests (requires certificate and key)")
+	flag.StringVar(&certFile, "tls-cert-file", "", "File containing the TLS certificate")
+	flag.StringVar(&keyFile, "tls-key-file", "", "File containing the TLS private key")
+
+	flag.Parse()
+
+	handler := echo.Handler(echo.Backend{
+		Namespace: os.Getenv("NAMESPACE"),
+		Pod:       os.Getenv("POD_NAME"),
+		Service:   os.Getenv("SERVICE_NAME"),
+	})
+
+	if certFile != "" && keyFile != "" {
+		go func() {
+			log.Printf("Listening HTTPS requests in %v", httpsAddress)
+			log.Fatal(http.ListenAndServeTLS(httpsAddress, certFile, keyFile, handler))
+		}()
+	}
+
+	log.Printf("Listening HTTP requests in %v", httpAddress)
+	log.Fatal(http.ListenAndServe(httpAddress, handler))
+}
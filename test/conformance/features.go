@@ -0,0 +1,22 @@
+// Code generated by hack/codegen.go. DO NOT EDIT.
+
+package conformance
+
+import (
+	"github.com/aledbf/ingress-conformance-bdd/test/conformance/defaultbackend"
+	"github.com/aledbf/ingress-conformance-bdd/test/conformance/hostmatching"
+	"github.com/aledbf/ingress-conformance-bdd/test/conformance/ingressclass"
+	"github.com/aledbf/ingress-conformance-bdd/test/conformance/pathmatching"
+	"github.com/aledbf/ingress-conformance-bdd/test/conformance/tlstermination"
+	"github.com/aledbf/ingress-conformance-bdd/test/conformance/withouthost"
+)
+
+// Features contains the features of the suite sorted by path
+var Features = []Feature{
+	{Path: "features/default_backend.feature", Context: defaultbackend.FeatureContext},
+	{Path: "features/host_matching.feature", Context: hostmatching.FeatureContext},
+	{Path: "features/ingress_class.feature", Context: ingressclass.FeatureContext},
+	{Path: "features/path_matching.feature", Context: pathmatching.FeatureContext},
+	{Path: "features/tls_termination.feature", Context: tlstermination.FeatureContext},
+	{Path: "features/without_host.feature", Context: withouthost.FeatureContext},
+}
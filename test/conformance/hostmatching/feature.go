@@ -0,0 +1,11 @@
+package hostmatching
+
+import (
+	"github.com/cucumber/godog"
+
+	tstate "github.com/aledbf/ingress-conformance-bdd/test/state"
+)
+
+// FeatureContext registers the steps of the feature. All the steps
+// are provided by the package test/steps, registered by TestSuite.
+func FeatureContext(s *godog.Suite, state *tstate.Scenario) {}
@@ -0,0 +1,175 @@
+package ingressclass
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/cucumber/godog"
+	"github.com/cucumber/messages-go/v10"
+	"k8s.io/klog"
+
+	tstate "github.com/aledbf/ingress-conformance-bdd/test/state"
+	"github.com/aledbf/ingress-conformance-bdd/test/utils"
+)
+
+// feature holds the state of a running scenario. The state is
+// shared with the common steps and replaced before every scenario.
+type feature struct {
+	// holds state of the scenarario
+	state *tstate.Scenario
+
+	// names of the IngressClasses created in the scenario. The last one
+	// is referenced by the Ingress
+	ingressClasses []string
+
+	// true when the scenario marked the IngressClass used by the suite as default
+	defaultClassChanged bool
+	// value of the default annotation before the scenario changed it
+	previousDefault string
+	// IngressClasses of the cluster no longer marked as default by the
+	// scenario, with the previous value of the annotation
+	otherDefaults map[string]string
+}
+
+func (f *feature) aNewIngressClassWithController(arg1 string) error {
+	class, err := utils.CreateIngressClass(utils.KubeClient, "", arg1)
+	if err != nil {
+		return err
+	}
+
+	f.ingressClasses = append(f.ingressClasses, class.Name)
+
+	return nil
+}
+
+func (f *feature) theIngressReferencesTheNewIngressClass() error {
+	if len(f.ingressClasses) == 0 {
+		return fmt.Errorf("scenario without IngressClass associated")
+	}
+
+	class := f.ingressClasses[len(f.ingressClasses)-1]
+
+	delete(f.state.Ingress.Annotations, utils.IngressClassKey)
+	f.state.Ingress.Spec.IngressClassName = &class
+
+	return nil
+}
+
+func (f *feature) theIngressStatusDoesNotShowAnAddressAfterSeconds(arg1 int) error {
+	return utils.WaitForIngressWithoutAddress(utils.KubeClient, f.state.Namespace,
+		f.state.Ingress.GetName(), time.Duration(arg1)*time.Second)
+}
+
+func (f *feature) theIngressClassUsedByTheSuiteIsTheDefault() error {
+	classes, err := utils.ListIngressClasses(utils.KubeClient)
+	if err != nil {
+		return err
+	}
+
+	isDefault := false
+	for i := range classes {
+		class := &classes[i]
+		if class.Name == utils.IngressClassValue {
+			isDefault = utils.IsDefaultIngressClass(class)
+			continue
+		}
+
+		if !utils.IsDefaultIngressClass(class) {
+			continue
+		}
+
+		// only one IngressClass can be the default. The annotation of
+		// other classes is removed and restored after the scenario
+		previous, err := utils.SetDefaultIngressClass(utils.KubeClient, class.Name, "")
+		if err != nil {
+			return err
+		}
+
+		f.otherDefaults[class.Name] = previous
+	}
+
+	if isDefault {
+		return nil
+	}
+
+	previous, err := utils.SetDefaultIngressClass(utils.KubeClient, utils.IngressClassValue, "true")
+	if err != nil {
+		return err
+	}
+
+	f.defaultClassChanged = true
+	f.previousDefault = previous
+
+	return nil
+}
+
+func (f *feature) theIngressDoesNotReferenceAClass() error {
+	delete(f.state.Ingress.Annotations, utils.IngressClassKey)
+	f.state.Ingress.Spec.IngressClassName = nil
+
+	return nil
+}
+
+func (f *feature) theIngressIsAssignedTheIngressClassUsedByTheSuite() error {
+	ing, err := utils.GetIngress(utils.KubeClient, f.state.Namespace, f.state.Ingress.GetName())
+	if err != nil {
+		return err
+	}
+
+	if ing.Spec.IngressClassName == nil {
+		return fmt.Errorf("expected Ingress with spec.ingressClassName %v but the field is empty",
+			utils.IngressClassValue)
+	}
+
+	if *ing.Spec.IngressClassName != utils.IngressClassValue {
+		return fmt.Errorf("expected Ingress with spec.ingressClassName %v but %v was returned",
+			utils.IngressClassValue, *ing.Spec.IngressClassName)
+	}
+
+	f.state.Ingress = ing
+
+	return nil
+}
+
+// FeatureContext registers the steps of the feature. Steps
+// defined in the package test/steps are registered by TestSuite.
+func FeatureContext(s *godog.Suite, state *tstate.Scenario) {
+	f := &feature{state: state}
+
+	s.Step(`^a new IngressClass with controller "([^"]*)"$`, f.aNewIngressClassWithController)
+	s.Step(`^the Ingress references the new IngressClass$`, f.theIngressReferencesTheNewIngressClass)
+	s.Step(`^the ingress status does not show an address after (\d+) seconds$`, f.theIngressStatusDoesNotShowAnAddressAfterSeconds)
+	s.Step(`^the IngressClass used by the suite is the default$`, f.theIngressClassUsedByTheSuiteIsTheDefault)
+	s.Step(`^the Ingress does not reference a class$`, f.theIngressDoesNotReferenceAClass)
+	s.Step(`^the Ingress is assigned the IngressClass used by the suite$`, f.theIngressIsAssignedTheIngressClassUsedByTheSuite)
+
+	s.BeforeScenario(func(this *messages.Pickle) {
+		f.ingressClasses = nil
+		f.defaultClassChanged = false
+		f.previousDefault = ""
+		f.otherDefaults = map[string]string{}
+	})
+
+	// IngressClasses are not namespaced, the cluster is restored
+	// removing the classes created and the default changed
+	s.AfterScenario(func(*messages.Pickle, error) {
+		for _, class := range f.ingressClasses {
+			if err := utils.DeleteIngressClass(utils.KubeClient, class); err != nil {
+				klog.Warningf("Error deleting IngressClass %v: %v", class, err)
+			}
+		}
+
+		if f.defaultClassChanged {
+			_, err := utils.SetDefaultIngressClass(utils.KubeClient, utils.IngressClassValue, f.previousDefault)
+			if err != nil {
+				klog.Warningf("Error restoring the default IngressClass %v: %v", utils.IngressClassValue, err)
+			}
+		}
+
+		for class, previous := range f.otherDefaults {
+			if _, err := utils.SetDefaultIngressClass(utils.KubeClient, class, previous); err != nil {
+				klog.Warningf("Error restoring the default annotation of IngressClass %v: %v", class, err)
+			}
+		}
+	})
+}
@@ -0,0 +1,123 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package level defines the conformance levels (tiers) of the features.
+// Each feature declares exactly one level using a tag (i.e. @core).
+package level
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Level is a conformance level
+type Level string
+
+const (
+	// Core features must be supported by all the ingress controllers
+	Core Level = "core"
+	// Extended features are optional but portable between controllers
+	Extended Level = "extended"
+	// ImplementationSpecific features depend on the ingress controller
+	ImplementationSpecific Level = "implementation-specific"
+)
+
+// Levels contains the conformance levels, from the lowest to the highest.
+// Running a level includes the features of the lower levels.
+var Levels = []Level{Core, Extended, ImplementationSpecific}
+
+// Tag returns the tag used in feature files (i.e. @core)
+func (l Level) Tag() string {
+	return "@" + string(l)
+}
+
+// Tags returns the godog tag expression selecting the
+// scenarios of the level and the lower levels
+func (l Level) Tags() string {
+	var tags []string
+	for _, level := range Levels {
+		tags = append(tags, level.Tag())
+		if level == l {
+			break
+		}
+	}
+
+	return strings.Join(tags, ",")
+}
+
+// Includes checks if the level includes the features of other level
+func (l Level) Includes(other Level) bool {
+	return index(other) <= index(l)
+}
+
+// Parse returns the level with the specified name
+func Parse(name string) (Level, error) {
+	for _, level := range Levels {
+		if string(level) == name {
+			return level, nil
+		}
+	}
+
+	return "", fmt.Errorf("invalid conformance level %q (valid levels: %v)", name, names())
+}
+
+// Of returns the level declared in the tags of a feature. The
+// tags must contain exactly one of the tags of the levels.
+func Of(tags []string) (Level, error) {
+	var found []Level
+
+	for _, tag := range tags {
+		for _, level := range Levels {
+			if tag == level.Tag() {
+				found = append(found, level)
+			}
+		}
+	}
+
+	if len(found) != 1 {
+		return "", fmt.Errorf("expected exactly one conformance level tag (%v) but found %v", tagNames(), len(found))
+	}
+
+	return found[0], nil
+}
+
+func index(l Level) int {
+	for i, level := range Levels {
+		if level == l {
+			return i
+		}
+	}
+
+	return len(Levels)
+}
+
+func names() string {
+	var result []string
+	for _, level := range Levels {
+		result = append(result, string(level))
+	}
+
+	return strings.Join(result, ", ")
+}
+
+func tagNames() string {
+	var result []string
+	for _, level := range Levels {
+		result = append(result, level.Tag())
+	}
+
+	return strings.Join(result, ", ")
+}
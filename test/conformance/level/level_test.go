@@ -0,0 +1,67 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package level
+
+import (
+	"testing"
+)
+
+func TestTags(t *testing.T) {
+	testCases := map[Level]string{
+		Core:                   "@core",
+		Extended:               "@core,@extended",
+		ImplementationSpecific: "@core,@extended,@implementation-specific",
+	}
+
+	for level, expected := range testCases {
+		if tags := level.Tags(); tags != expected {
+			t.Errorf("expected tags %q for level %v but %q was returned", expected, level, tags)
+		}
+	}
+}
+
+func TestOf(t *testing.T) {
+	level, err := Of([]string{"@sig-network", "@extended", "@tls"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if level != Extended {
+		t.Errorf("expected level %v but %v was returned", Extended, level)
+	}
+
+	for _, tags := range [][]string{{"@tls"}, {"@core", "@extended"}} {
+		if _, err := Of(tags); err == nil {
+			t.Errorf("expected an error using tags %v", tags)
+		}
+	}
+}
+
+func TestParse(t *testing.T) {
+	if _, err := Parse("basic"); err == nil {
+		t.Errorf("expected an error parsing an invalid level")
+	}
+
+	level, err := Parse("core")
+	if err != nil || level != Core {
+		t.Errorf("expected level %v but %v was returned (%v)", Core, level, err)
+	}
+
+	if !Extended.Includes(Core) || Core.Includes(Extended) {
+		t.Errorf("expected levels to include the lower levels")
+	}
+}
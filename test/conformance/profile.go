@@ -0,0 +1,165 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package conformance
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/util/sets"
+	utilyaml "k8s.io/apimachinery/pkg/util/yaml"
+)
+
+// Profile describes the capabilities of an ingress controller using the
+// tags of the features and scenarios. For example, a controller without
+// support for wildcard hosts declares the tag @wildcard-host as unsupported.
+type Profile struct {
+	// Controller is the name of the ingress controller
+	Controller string `json:"controller"`
+	// Supported tags. When defined, only the scenarios with at least one of the tags run
+	Supported []string `json:"supported,omitempty"`
+	// Unsupported tags. Scenarios with any of the tags do not run
+	Unsupported []string `json:"unsupported,omitempty"`
+}
+
+// LoadProfile reads a profile from a YAML (or JSON) file
+func LoadProfile(file string) (*Profile, error) {
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("error reading profile %v: %v", file, err)
+	}
+
+	profile := &Profile{}
+	if err := utilyaml.NewYAMLOrJSONDecoder(bytes.NewReader(data), len(data)).Decode(profile); err != nil {
+		return nil, fmt.Errorf("error parsing profile %v: %v", file, err)
+	}
+
+	if err := profile.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid profile %v: %v", file, err)
+	}
+
+	return profile, nil
+}
+
+// Validate checks the profile names the controller and the tags
+// start with @ and are not supported and unsupported at the same time
+func (p *Profile) Validate() error {
+	if p.Controller == "" {
+		return fmt.Errorf("the name of the controller is required")
+	}
+
+	for _, tag := range append(append([]string{}, p.Supported...), p.Unsupported...) {
+		if !strings.HasPrefix(tag, "@") || strings.ContainsAny(tag, " ,~") {
+			return fmt.Errorf("invalid tag %q (tags must start with @)", tag)
+		}
+	}
+
+	both := sets.NewString(p.Supported...).Intersection(sets.NewString(p.Unsupported...))
+	if both.Len() != 0 {
+		return fmt.Errorf("tags %v are supported and unsupported", strings.Join(both.List(), ", "))
+	}
+
+	return nil
+}
+
+// Tags returns the godog tag expression selecting the scenarios supported by the controller
+func (p *Profile) Tags() string {
+	var expressions []string
+
+	if len(p.Supported) != 0 {
+		expressions = append(expressions, strings.Join(p.Supported, ","))
+	}
+
+	for _, tag := range p.Unsupported {
+		expressions = append(expressions, "~"+tag)
+	}
+
+	return strings.Join(expressions, " && ")
+}
+
+// Excludes checks if a scenario using the tags does not run with the profile
+func (p *Profile) Excludes(tags []string) bool {
+	return !MatchTags(p.Tags(), tags)
+}
+
+// IsUnsupported checks if the profile excludes all the scenarios of a feature file
+func (p *Profile) IsUnsupported(file string) (bool, error) {
+	scenarios, err := ScenarioTags(file)
+	if err != nil {
+		return false, err
+	}
+
+	for _, tags := range scenarios {
+		if !p.Excludes(tags) {
+			return false, nil
+		}
+	}
+
+	return true, nil
+}
+
+// MatchTags checks if the tags of a scenario match a godog tag expression,
+// where "&&" requires all the terms, "," separates alternatives and "~"
+// negates a tag. An empty expression matches any scenario.
+func MatchTags(expression string, tags []string) bool {
+	if strings.TrimSpace(expression) == "" {
+		return true
+	}
+
+	set := sets.NewString()
+	for _, tag := range tags {
+		set.Insert(strings.TrimPrefix(tag, "@"))
+	}
+
+	for _, term := range strings.Split(expression, "&&") {
+		matched := false
+
+		for _, tag := range strings.Split(term, ",") {
+			tag = strings.ReplaceAll(strings.TrimSpace(tag), "@", "")
+			if strings.HasPrefix(tag, "~") {
+				matched = matched || !set.Has(tag[1:])
+			} else {
+				matched = matched || set.Has(tag)
+			}
+		}
+
+		if !matched {
+			return false
+		}
+	}
+
+	return true
+}
+
+// AndTags combines two godog tag expressions
+func AndTags(tags, expression string) string {
+	switch {
+	case tags == "":
+		return expression
+	case expression == "":
+		return tags
+	default:
+		return fmt.Sprintf("%v && %v", tags, expression)
+	}
+}
+
+// ExcludeTag appends a negated tag to a godog tag expression
+func ExcludeTag(tags, tag string) string {
+	return AndTags(tags, "~"+tag)
+}
@@ -0,0 +1,119 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package conformance
+
+import (
+	"testing"
+)
+
+func TestProfileTags(t *testing.T) {
+	testCases := []struct {
+		profile  Profile
+		expected string
+	}{
+		{Profile{Controller: "test"}, ""},
+		{Profile{Controller: "test", Supported: []string{"@conformance"}}, "@conformance"},
+		{Profile{Controller: "test", Unsupported: []string{"@tls", "@wildcard-host"}}, "~@tls && ~@wildcard-host"},
+		{
+			Profile{Controller: "test", Supported: []string{"@conformance", "@extra"}, Unsupported: []string{"@tls"}},
+			"@conformance,@extra && ~@tls",
+		},
+	}
+
+	for _, tc := range testCases {
+		if tags := tc.profile.Tags(); tags != tc.expected {
+			t.Errorf("expected tags %q but %q was returned", tc.expected, tags)
+		}
+	}
+}
+
+func TestProfileValidate(t *testing.T) {
+	invalid := []Profile{
+		{Supported: []string{"@conformance"}},
+		{Controller: "test", Unsupported: []string{"tls"}},
+		{Controller: "test", Unsupported: []string{"@tls,@wildcard-host"}},
+		{Controller: "test", Supported: []string{"@tls"}, Unsupported: []string{"@tls"}},
+	}
+
+	for _, profile := range invalid {
+		if err := profile.Validate(); err == nil {
+			t.Errorf("expected an error validating profile %+v", profile)
+		}
+	}
+}
+
+func TestProfileIsUnsupported(t *testing.T) {
+	testCases := []struct {
+		profile     Profile
+		file        string
+		unsupported bool
+	}{
+		{Profile{Unsupported: []string{"@wildcard-host"}}, "host_matching.feature", false},
+		{Profile{Unsupported: []string{"@host-matching"}}, "host_matching.feature", true},
+		{Profile{Supported: []string{"@tls"}}, "host_matching.feature", true},
+		{Profile{Supported: []string{"@wildcard-host"}}, "host_matching.feature", false},
+	}
+
+	for _, tc := range testCases {
+		unsupported, err := tc.profile.IsUnsupported("../../features/" + tc.file)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if unsupported != tc.unsupported {
+			t.Errorf("expected unsupported %v for feature %v using profile %+v", tc.unsupported, tc.file, tc.profile)
+		}
+	}
+}
+
+func TestLoadProfile(t *testing.T) {
+	profile, err := LoadProfile("../../profiles/example.yaml")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if profile.Tags() != "@conformance && ~@wildcard-host" {
+		t.Errorf("unexpected tags %q", profile.Tags())
+	}
+}
+
+func TestAndTags(t *testing.T) {
+	testCases := []struct {
+		tags       string
+		expression string
+		expected   string
+	}{
+		{tags: "", expression: "", expected: ""},
+		{tags: "@core", expression: "", expected: "@core"},
+		{tags: "", expression: "@core,@extended", expected: "@core,@extended"},
+		{tags: "@tls", expression: "@core,@extended", expected: "@tls && @core,@extended"},
+	}
+
+	for _, tc := range testCases {
+		if tags := AndTags(tc.tags, tc.expression); tags != tc.expected {
+			t.Errorf("expected %q combining %q and %q but got %q", tc.expected, tc.tags, tc.expression, tags)
+		}
+	}
+
+	if tags := ExcludeTag("@core", "@ingress-class"); tags != "@core && ~@ingress-class" {
+		t.Errorf("expected tag expression excluding @ingress-class but got %q", tags)
+	}
+
+	if tags := ExcludeTag("", "@ingress-class"); tags != "~@ingress-class" {
+		t.Errorf("expected tag expression excluding @ingress-class but got %q", tags)
+	}
+}
@@ -0,0 +1,148 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package conformance contains the registry of the features of the suite.
+// Each feature file located in the directory features has a package in this
+// directory providing the context that registers the steps of the feature.
+package conformance
+
+import (
+	"fmt"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/cucumber/godog"
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	tstate "github.com/aledbf/ingress-conformance-bdd/test/state"
+)
+
+// FeatureContext registers the steps of a feature
+type FeatureContext func(*godog.Suite, *tstate.Scenario)
+
+// Feature associates a feature file with the context registering its steps
+type Feature struct {
+	// Path of the feature file (i.e. features/default_backend.feature)
+	Path string
+	// Context registers the steps of the feature
+	Context FeatureContext
+}
+
+// Name returns the name of the feature file without extension (i.e. default_backend)
+func (f Feature) Name() string {
+	return strings.TrimSuffix(path.Base(f.Path), ".feature")
+}
+
+// Validate checks every feature file located in the directory is
+// registered and every registered feature has a feature file.
+func Validate(features []Feature, dir string) error {
+	files, err := filepath.Glob(filepath.Join(dir, "*.feature"))
+	if err != nil {
+		return err
+	}
+
+	inDirectory := sets.NewString()
+	for _, file := range files {
+		inDirectory.Insert(filepath.Base(file))
+	}
+
+	registered := sets.NewString()
+	for _, feature := range features {
+		registered.Insert(path.Base(feature.Path))
+	}
+
+	var problems []string
+
+	for _, file := range inDirectory.Difference(registered).List() {
+		problems = append(problems, fmt.Sprintf("feature file %v does not have a context (run make codegen)", file))
+	}
+
+	for _, file := range registered.Difference(inDirectory).List() {
+		problems = append(problems, fmt.Sprintf("feature %v is registered but the file does not exist in %v", file, dir))
+	}
+
+	if len(problems) != 0 {
+		return fmt.Errorf("invalid features:\n- %v", strings.Join(problems, "\n- "))
+	}
+
+	return nil
+}
+
+// Select returns the features matching at least one of the patterns. Patterns
+// are feature names (i.e. host_matching) or globs (i.e. host_*). Using a
+// pattern that does not match any feature returns an error.
+func Select(features []Feature, patterns []string) ([]Feature, error) {
+	if len(patterns) == 0 {
+		return features, nil
+	}
+
+	selected := map[string]Feature{}
+
+	for _, pattern := range patterns {
+		pattern = strings.TrimSuffix(strings.TrimSpace(pattern), ".feature")
+
+		matched := false
+		for _, feature := range features {
+			ok, err := path.Match(pattern, feature.Name())
+			if err != nil {
+				return nil, fmt.Errorf("invalid feature pattern %v: %v", pattern, err)
+			}
+
+			if ok {
+				selected[feature.Path] = feature
+				matched = true
+			}
+		}
+
+		if !matched {
+			return nil, fmt.Errorf("pattern %v does not match any feature (%v)", pattern, strings.Join(names(features), ", "))
+		}
+	}
+
+	var result []Feature
+	for _, feature := range selected {
+		result = append(result, feature)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Path < result[j].Path
+	})
+
+	return result, nil
+}
+
+// SplitList returns the non-empty elements of a comma separated list
+func SplitList(list string) []string {
+	var items []string
+	for _, item := range strings.Split(list, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+
+	return items
+}
+
+func names(features []Feature) []string {
+	var result []string
+	for _, feature := range features {
+		result = append(result, feature.Name())
+	}
+
+	return result
+}
@@ -0,0 +1,184 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package conformance
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+var registry = []Feature{
+	{Path: "features/path_matching.feature"},
+	{Path: "features/host_matching.feature"},
+	{Path: "features/default_backend.feature"},
+	{Path: "features/path_rules.feature"},
+}
+
+func TestValidate(t *testing.T) {
+	testCases := []struct {
+		name     string
+		files    []string
+		expected []string
+	}{
+		{
+			name:  "registered features",
+			files: []string{"path_matching.feature", "host_matching.feature", "default_backend.feature", "path_rules.feature", "README.md"},
+		},
+		{
+			name:  "unregistered file",
+			files: []string{"path_matching.feature", "host_matching.feature", "default_backend.feature", "path_rules.feature", "new.feature"},
+			expected: []string{
+				"feature file new.feature does not have a context (run make codegen)",
+			},
+		},
+		{
+			name:  "missing files",
+			files: []string{"path_matching.feature", "default_backend.feature"},
+			expected: []string{
+				"feature host_matching.feature is registered but the file does not exist in",
+				"feature path_rules.feature is registered but the file does not exist in",
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			dir := t.TempDir()
+			for _, file := range tc.files {
+				if err := ioutil.WriteFile(filepath.Join(dir, file), []byte("Feature: test\n"), 0644); err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+			}
+
+			err := Validate(registry, dir)
+			if len(tc.expected) == 0 {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+
+				return
+			}
+
+			if err == nil {
+				t.Fatalf("expected an error")
+			}
+
+			problems := strings.Split(err.Error(), "\n- ")[1:]
+			if len(problems) != len(tc.expected) {
+				t.Fatalf("expected %v problems but got %v", len(tc.expected), err)
+			}
+
+			for i, problem := range problems {
+				if !strings.HasPrefix(problem, tc.expected[i]) {
+					t.Errorf("expected %q but got %q", tc.expected[i], problem)
+				}
+			}
+		})
+	}
+}
+
+func TestValidateFeatures(t *testing.T) {
+	if err := Validate(Features, "../../features"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestSelect(t *testing.T) {
+	testCases := []struct {
+		name      string
+		patterns  []string
+		expected  []string
+		expectErr string
+	}{
+		{
+			name:     "no patterns",
+			expected: []string{"path_matching", "host_matching", "default_backend", "path_rules"},
+		},
+		{
+			name:     "name",
+			patterns: []string{"host_matching"},
+			expected: []string{"host_matching"},
+		},
+		{
+			name:     "name with .feature suffix",
+			patterns: []string{" host_matching.feature "},
+			expected: []string{"host_matching"},
+		},
+		{
+			name:     "glob",
+			patterns: []string{"path_*"},
+			expected: []string{"path_matching", "path_rules"},
+		},
+		{
+			name:     "sorted and deduplicated",
+			patterns: []string{"path_rules", "*_matching", "path_*", "default_backend"},
+			expected: []string{"default_backend", "host_matching", "path_matching", "path_rules"},
+		},
+		{
+			name:      "pattern without matches",
+			patterns:  []string{"host_matching", "tls_*"},
+			expectErr: "pattern tls_* does not match any feature (path_matching, host_matching, default_backend, path_rules)",
+		},
+		{
+			name:      "invalid pattern",
+			patterns:  []string{"[host"},
+			expectErr: "invalid feature pattern [host: syntax error in pattern",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			selected, err := Select(registry, tc.patterns)
+			if tc.expectErr != "" {
+				if err == nil || err.Error() != tc.expectErr {
+					t.Fatalf("expected error %q but got %v", tc.expectErr, err)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if !reflect.DeepEqual(names(selected), tc.expected) {
+				t.Errorf("expected features %v but got %v", tc.expected, names(selected))
+			}
+		})
+	}
+}
+
+func TestSplitList(t *testing.T) {
+	testCases := []struct {
+		list     string
+		expected []string
+	}{
+		{list: ""},
+		{list: " , ,"},
+		{list: "host_matching", expected: []string{"host_matching"}},
+		{list: " host_matching, path_*,,tls_termination ", expected: []string{"host_matching", "path_*", "tls_termination"}},
+	}
+
+	for _, tc := range testCases {
+		if items := SplitList(tc.list); !reflect.DeepEqual(items, tc.expected) {
+			t.Errorf("expected %v splitting %q but got %v", tc.expected, tc.list, items)
+		}
+	}
+}
@@ -0,0 +1,173 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package conformance
+
+import (
+	"bytes"
+	"fmt"
+	"text/tabwriter"
+	"time"
+
+	"github.com/aledbf/ingress-conformance-bdd/test/conformance/level"
+)
+
+// Status of a feature
+const (
+	FeaturePassed  = "passed"
+	FeatureFailed  = "failed"
+	FeatureSkipped = "skipped"
+)
+
+// Reasons to skip a feature
+const (
+	SkippedAfterFailure = "after a failed feature"
+	SkippedUnsupported  = "unsupported by profile"
+)
+
+// FeatureResult contains the result of the execution of a feature
+type FeatureResult struct {
+	Feature  string
+	Status   string
+	Duration time.Duration
+	Error    error
+	// Reason explains why the feature was skipped
+	Reason string
+	// Level is the conformance level of the feature
+	Level level.Level
+	// Excluded is the number of scenarios not executed due to the tag expression
+	Excluded int
+}
+
+// SkipReason returns the reason to skip a feature of a level, or an empty string
+// if the feature must run. A failed feature only skips the remaining features
+// when stopOnFailure is true (flag --stop-on-failure).
+func SkipReason(featureLevel, runLevel level.Level, failed, stopOnFailure bool) string {
+	if failed && stopOnFailure {
+		return SkippedAfterFailure
+	}
+
+	if !runLevel.Includes(featureLevel) {
+		return fmt.Sprintf("level %v not selected", featureLevel)
+	}
+
+	return ""
+}
+
+// ExitCode returns the exit code of the suite: 1 if any feature failed
+func ExitCode(results []FeatureResult) int {
+	for _, result := range results {
+		if result.Status == FeatureFailed {
+			return 1
+		}
+	}
+
+	return 0
+}
+
+// LevelPassed returns the highest conformance level, up to runLevel, where all
+// the features of the level, and the lower levels, passed running all their
+// scenarios. Levels without features do not raise the level passed.
+func LevelPassed(results []FeatureResult, runLevel level.Level) level.Level {
+	var passed level.Level
+
+	for _, l := range level.Levels {
+		if !runLevel.Includes(l) {
+			break
+		}
+
+		features := 0
+		for _, result := range results {
+			if result.Level != l {
+				continue
+			}
+
+			if result.Status != FeaturePassed || result.Excluded != 0 {
+				return passed
+			}
+
+			features++
+		}
+
+		if features == 0 {
+			break
+		}
+
+		passed = l
+	}
+
+	return passed
+}
+
+// LevelDescription returns a line stating the conformance level passed in full.
+// filtered is true when the features were selected with the flag --feature.
+func LevelDescription(passed level.Level, filtered bool) string {
+	description := "Conformance level: no level passed in full"
+	if passed != "" {
+		description = fmt.Sprintf("Conformance level: %v passed in full", passed)
+	}
+
+	if filtered {
+		description += " (only the features selected with --feature)"
+	}
+
+	return description
+}
+
+// FormatResults returns a table with the result of each feature, in order,
+// followed by the number of features by status and the level passed
+func FormatResults(results []FeatureResult, passed level.Level, filtered bool) string {
+	buf := &bytes.Buffer{}
+
+	w := tabwriter.NewWriter(buf, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "FEATURE\tLEVEL\tSTATUS\tDURATION")
+
+	counts := map[string]int{}
+	unsupported := 0
+
+	for _, result := range results {
+		counts[result.Status]++
+		if result.Reason == SkippedUnsupported {
+			unsupported++
+		}
+
+		status := result.Status
+		if result.Reason != "" {
+			status = fmt.Sprintf("%v: %v", status, result.Reason)
+		}
+
+		if result.Error != nil {
+			status = fmt.Sprintf("%v (%v)", status, result.Error)
+		}
+
+		if result.Excluded != 0 {
+			status = fmt.Sprintf("%v (%v scenarios excluded by tags)", status, result.Excluded)
+		}
+
+		fmt.Fprintf(w, "%v\t%v\t%v\t%v\n", result.Feature, result.Level, status, result.Duration.Round(time.Second))
+	}
+
+	_ = w.Flush()
+
+	fmt.Fprintf(buf, "%v passed, %v failed, %v skipped", counts[FeaturePassed], counts[FeatureFailed], counts[FeatureSkipped])
+	if unsupported != 0 {
+		fmt.Fprintf(buf, " (%v %v)", unsupported, SkippedUnsupported)
+	}
+
+	fmt.Fprintf(buf, "\n%v", LevelDescription(passed, filtered))
+
+	return buf.String()
+}
@@ -0,0 +1,245 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package conformance
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/aledbf/ingress-conformance-bdd/test/conformance/level"
+)
+
+func TestSkipReason(t *testing.T) {
+	testCases := []struct {
+		name          string
+		featureLevel  level.Level
+		runLevel      level.Level
+		failed        bool
+		stopOnFailure bool
+		expected      string
+	}{
+		{
+			name:         "runs",
+			featureLevel: level.Core,
+			runLevel:     level.ImplementationSpecific,
+		},
+		{
+			name:         "continues after a failed feature",
+			featureLevel: level.Extended,
+			runLevel:     level.ImplementationSpecific,
+			failed:       true,
+		},
+		{
+			name:          "stops after a failed feature",
+			featureLevel:  level.Core,
+			runLevel:      level.ImplementationSpecific,
+			failed:        true,
+			stopOnFailure: true,
+			expected:      SkippedAfterFailure,
+		},
+		{
+			name:          "no failed feature with stop on failure",
+			featureLevel:  level.Core,
+			runLevel:      level.Core,
+			stopOnFailure: true,
+		},
+		{
+			name:         "level not selected",
+			featureLevel: level.Extended,
+			runLevel:     level.Core,
+			expected:     "level extended not selected",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			reason := SkipReason(tc.featureLevel, tc.runLevel, tc.failed, tc.stopOnFailure)
+			if reason != tc.expected {
+				t.Errorf("expected reason %q but got %q", tc.expected, reason)
+			}
+		})
+	}
+}
+
+func TestExitCode(t *testing.T) {
+	testCases := []struct {
+		name     string
+		statuses []string
+		expected int
+	}{
+		{
+			name:     "no features",
+			expected: 0,
+		},
+		{
+			name:     "passed and skipped features",
+			statuses: []string{FeaturePassed, FeatureSkipped, FeaturePassed},
+			expected: 0,
+		},
+		{
+			name:     "failed feature followed by passed features",
+			statuses: []string{FeatureFailed, FeaturePassed, FeaturePassed},
+			expected: 1,
+		},
+		{
+			name:     "failed feature after skipped features",
+			statuses: []string{FeatureSkipped, FeatureSkipped, FeatureFailed},
+			expected: 1,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var results []FeatureResult
+			for _, status := range tc.statuses {
+				results = append(results, FeatureResult{Status: status})
+			}
+
+			if code := ExitCode(results); code != tc.expected {
+				t.Errorf("expected exit code %v but got %v", tc.expected, code)
+			}
+		})
+	}
+}
+
+func TestLevelPassed(t *testing.T) {
+	passed := func(l level.Level) FeatureResult {
+		return FeatureResult{Status: FeaturePassed, Level: l}
+	}
+
+	testCases := []struct {
+		name     string
+		runLevel level.Level
+		results  []FeatureResult
+		expected level.Level
+	}{
+		{
+			name:     "all the levels passed",
+			runLevel: level.ImplementationSpecific,
+			results:  []FeatureResult{passed(level.Core), passed(level.Extended), passed(level.ImplementationSpecific)},
+			expected: level.ImplementationSpecific,
+		},
+		{
+			name:     "the order of the results is not relevant",
+			runLevel: level.ImplementationSpecific,
+			results:  []FeatureResult{passed(level.ImplementationSpecific), passed(level.Core), passed(level.Extended)},
+			expected: level.ImplementationSpecific,
+		},
+		{
+			name:     "limited by the level selected",
+			runLevel: level.Core,
+			results:  []FeatureResult{passed(level.Core), passed(level.Extended)},
+			expected: level.Core,
+		},
+		{
+			name:     "failed feature of a higher level",
+			runLevel: level.ImplementationSpecific,
+			results: []FeatureResult{
+				passed(level.Core),
+				{Status: FeatureFailed, Level: level.Extended},
+				passed(level.ImplementationSpecific),
+			},
+			expected: level.Core,
+		},
+		{
+			name:     "failed core feature",
+			runLevel: level.ImplementationSpecific,
+			results: []FeatureResult{
+				{Status: FeatureFailed, Level: level.Core},
+				passed(level.Core),
+				passed(level.Extended),
+			},
+		},
+		{
+			name:     "skipped feature",
+			runLevel: level.ImplementationSpecific,
+			results: []FeatureResult{
+				passed(level.Core),
+				{Status: FeatureSkipped, Reason: SkippedUnsupported, Level: level.Extended},
+			},
+			expected: level.Core,
+		},
+		{
+			name:     "scenarios excluded by tags",
+			runLevel: level.ImplementationSpecific,
+			results: []FeatureResult{
+				{Status: FeaturePassed, Level: level.Core, Excluded: 1},
+			},
+		},
+		{
+			name:     "level without features",
+			runLevel: level.ImplementationSpecific,
+			results:  []FeatureResult{passed(level.Core), passed(level.ImplementationSpecific)},
+			expected: level.Core,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if l := LevelPassed(tc.results, tc.runLevel); l != tc.expected {
+				t.Errorf("expected level %q but got %q", tc.expected, l)
+			}
+		})
+	}
+}
+
+func TestFormatResults(t *testing.T) {
+	testCases := []struct {
+		name     string
+		results  []FeatureResult
+		passed   level.Level
+		filtered bool
+		expected string
+	}{
+		{
+			name: "results in order after a failed feature",
+			results: []FeatureResult{
+				{Feature: "features/b.feature", Status: FeatureFailed, Level: level.Core, Duration: 2 * time.Second, Error: errors.New("timeout")},
+				{Feature: "features/a.feature", Status: FeaturePassed, Level: level.Core, Duration: 1400 * time.Millisecond, Excluded: 2},
+				{Feature: "features/c.feature", Status: FeatureSkipped, Level: level.Extended, Reason: SkippedUnsupported},
+			},
+			expected: `FEATURE             LEVEL     STATUS                                 DURATION
+features/b.feature  core      failed (timeout)                       2s
+features/a.feature  core      passed (2 scenarios excluded by tags)  1s
+features/c.feature  extended  skipped: unsupported by profile        0s
+1 passed, 1 failed, 1 skipped (1 unsupported by profile)
+Conformance level: no level passed in full`,
+		},
+		{
+			name: "features selected with --feature",
+			results: []FeatureResult{
+				{Feature: "features/a.feature", Status: FeaturePassed, Level: level.Core, Duration: time.Second},
+			},
+			passed:   level.Core,
+			filtered: true,
+			expected: `FEATURE             LEVEL  STATUS  DURATION
+features/a.feature  core   passed  1s
+1 passed, 0 failed, 0 skipped
+Conformance level: core passed in full (only the features selected with --feature)`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			table := FormatResults(tc.results, tc.passed, tc.filtered)
+			if table != tc.expected {
+				t.Errorf("expected\n%v\nbut got\n%v", tc.expected, table)
+			}
+		})
+	}
+}
@@ -0,0 +1,140 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package conformance
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+
+	"github.com/cucumber/gherkin-go/v11"
+	"github.com/cucumber/messages-go/v10"
+)
+
+// Scenario is a scenario (or an example of a scenario outline) of a feature file
+type Scenario struct {
+	// Feature is the name of the feature defining the scenario
+	Feature string
+	Name    string
+	// Line of the scenario definition, shared by the examples of an outline
+	Line int
+	// Tags of the scenario, including the tags of the feature and examples
+	Tags []string
+}
+
+// Scenarios returns the scenarios defined in a feature file
+func Scenarios(file string) ([]Scenario, error) {
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	gd, err := gherkin.ParseGherkinDocument(bytes.NewReader(data), (&messages.Incrementing{}).NewId)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing feature file %v: %v", file, err)
+	}
+
+	if gd.Feature == nil {
+		return nil, nil
+	}
+
+	// line of the scenario definitions by id
+	lines := map[string]int{}
+	for _, child := range gd.Feature.Children {
+		if scenario := child.GetScenario(); scenario != nil {
+			lines[scenario.GetId()] = int(scenario.GetLocation().GetLine())
+		}
+
+		for _, ruleChild := range child.GetRule().GetChildren() {
+			if scenario := ruleChild.GetScenario(); scenario != nil {
+				lines[scenario.GetId()] = int(scenario.GetLocation().GetLine())
+			}
+		}
+	}
+
+	var scenarios []Scenario
+	for _, pickle := range gherkin.Pickles(*gd, file, (&messages.Incrementing{}).NewId) {
+		scenario := Scenario{Feature: gd.Feature.Name, Name: pickle.Name}
+		if len(pickle.AstNodeIds) != 0 {
+			scenario.Line = lines[pickle.AstNodeIds[0]]
+		}
+
+		for _, tag := range pickle.Tags {
+			scenario.Tags = append(scenario.Tags, tag.Name)
+		}
+
+		scenarios = append(scenarios, scenario)
+	}
+
+	return scenarios, nil
+}
+
+// ScenarioTags returns the tags of each scenario (including the tags
+// of the feature and examples) defined in a feature file
+func ScenarioTags(file string) ([][]string, error) {
+	scenarios, err := Scenarios(file)
+	if err != nil {
+		return nil, err
+	}
+
+	var tags [][]string
+	for _, scenario := range scenarios {
+		tags = append(tags, scenario.Tags)
+	}
+
+	return tags, nil
+}
+
+// SerialTag identifies features changing cluster-scoped resources (i.e. the
+// default IngressClass). They run alone, with their scenarios in sequence.
+const SerialTag = "@serial"
+
+// IsSerial checks if the feature file, or any of its scenarios, is tagged @serial
+func IsSerial(file string) (bool, error) {
+	scenarios, err := ScenarioTags(file)
+	if err != nil {
+		return false, err
+	}
+
+	for _, tags := range scenarios {
+		for _, tag := range tags {
+			if tag == SerialTag {
+				return true, nil
+			}
+		}
+	}
+
+	return false, nil
+}
+
+// ScenarioLines returns the lines of the scenario definitions with at least
+// one scenario (or example) matching the godog tag expression, in order
+func ScenarioLines(scenarios []Scenario, expression string) []int {
+	var lines []int
+
+	seen := map[int]bool{}
+	for _, scenario := range scenarios {
+		if seen[scenario.Line] || !MatchTags(expression, scenario.Tags) {
+			continue
+		}
+
+		seen[scenario.Line] = true
+		lines = append(lines, scenario.Line)
+	}
+
+	return lines
+}
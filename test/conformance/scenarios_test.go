@@ -0,0 +1,155 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package conformance
+
+import (
+	"bytes"
+	"io/ioutil"
+	"reflect"
+	"testing"
+
+	"github.com/cucumber/gherkin-go/v11"
+	"github.com/cucumber/messages-go/v10"
+)
+
+func TestScenarios(t *testing.T) {
+	file := "../../features/host_matching.feature"
+
+	scenarios, err := Scenarios(file)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	lines, err := scenarioDefinitionLines(file)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var wildcard, examples []Scenario
+	for _, scenario := range scenarios {
+		expected, ok := lines[scenario.Name]
+		if !ok {
+			t.Errorf("unexpected scenario %q", scenario.Name)
+			continue
+		}
+
+		if scenario.Line != expected {
+			t.Errorf("expected scenario %q at line %v but got %v", scenario.Name, expected, scenario.Line)
+		}
+
+		if hasTag(scenario.Tags, "@wildcard-host") {
+			wildcard = append(wildcard, scenario)
+		}
+
+		if scenario.Name == "The same manifest serves requests for different hosts" {
+			examples = append(examples, scenario)
+		}
+	}
+
+	if len(wildcard) != 1 {
+		t.Errorf("expected one scenario with the tag @wildcard-host but got %v", len(wildcard))
+	}
+
+	// the examples of the outline share the line of the definition
+	if len(examples) < 2 {
+		t.Errorf("expected the examples of the outline but got %v scenarios", len(examples))
+	}
+}
+
+// scenarioDefinitionLines returns the line reported by the parser
+// for each scenario (or scenario outline) definition, by name
+func scenarioDefinitionLines(file string) (map[string]int, error) {
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	gd, err := gherkin.ParseGherkinDocument(bytes.NewReader(data), (&messages.Incrementing{}).NewId)
+	if err != nil {
+		return nil, err
+	}
+
+	lines := map[string]int{}
+	for _, child := range gd.Feature.Children {
+		if scenario := child.GetScenario(); scenario != nil {
+			lines[scenario.GetName()] = int(scenario.GetLocation().GetLine())
+		}
+	}
+
+	return lines, nil
+}
+
+func hasTag(tags []string, tag string) bool {
+	for _, t := range tags {
+		if t == tag {
+			return true
+		}
+	}
+
+	return false
+}
+
+func TestScenarioLines(t *testing.T) {
+	scenarios := []Scenario{
+		{Line: 3, Tags: []string{"@a"}},
+		{Line: 8, Tags: []string{"@a", "@b"}},
+		{Line: 12, Tags: []string{"@a"}},
+		{Line: 12, Tags: []string{"@a"}},
+	}
+
+	testCases := []struct {
+		expression string
+		lines      []int
+	}{
+		{"", []int{3, 8, 12}},
+		{"@b", []int{8}},
+		{"@a && ~@b", []int{3, 12}},
+		{"@c", nil},
+	}
+
+	for _, tc := range testCases {
+		lines := ScenarioLines(scenarios, tc.expression)
+		if !reflect.DeepEqual(lines, tc.lines) {
+			t.Errorf("expected lines %v for %q but got %v", tc.lines, tc.expression, lines)
+		}
+	}
+}
+
+func TestIsSerial(t *testing.T) {
+	testCases := []struct {
+		file     string
+		expected bool
+	}{
+		{file: "../../features/ingress_class.feature", expected: true},
+		{file: "../../features/host_matching.feature", expected: false},
+	}
+
+	for _, tc := range testCases {
+		serial, err := IsSerial(tc.file)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if serial != tc.expected {
+			t.Errorf("expected serial %v for %v but got %v", tc.expected, tc.file, serial)
+		}
+	}
+
+	if _, err := IsSerial("../../features/missing.feature"); err == nil {
+		t.Errorf("expected an error reading a missing feature")
+	}
+}
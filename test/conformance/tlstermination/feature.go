@@ -0,0 +1,134 @@
+package tlstermination
+
+import (
+	"crypto/x509"
+	"fmt"
+	"net/http"
+
+	"github.com/cucumber/godog"
+	"github.com/cucumber/messages-go/v10"
+
+	tstate "github.com/aledbf/ingress-conformance-bdd/test/state"
+	"github.com/aledbf/ingress-conformance-bdd/test/utils"
+)
+
+// feature holds the state of a running scenario. The state is
+// shared with the common steps and replaced before every scenario.
+type feature struct {
+	// holds state of the scenarario
+	state *tstate.Scenario
+
+	// CA used to sign the certificates generated in the scenario
+	ca *utils.Certificate
+}
+
+func (f *feature) aNewTLSCertificateForHostStoredInSecret(host string, secret string) error {
+	if f.ca == nil {
+		ca, err := utils.NewCA("ingress-conformance-ca")
+		if err != nil {
+			return err
+		}
+
+		f.ca = ca
+	}
+
+	cert, err := utils.NewServerCertificate(f.ca, host)
+	if err != nil {
+		return err
+	}
+
+	return utils.CreateTLSSecret(utils.KubeClient, f.state.Namespace, secret, cert)
+}
+
+func (f *feature) theTLSHandshakeSucceeds() error {
+	if f.state.TLS == nil {
+		return fmt.Errorf("the last request was not sent using TLS")
+	}
+
+	if !f.state.TLS.HandshakeComplete {
+		return fmt.Errorf("the TLS handshake did not complete")
+	}
+
+	return nil
+}
+
+func (f *feature) theServerCertificateContainsTheSAN(san string) error {
+	cert, err := f.serverCertificate()
+	if err != nil {
+		return err
+	}
+
+	if err := cert.VerifyHostname(san); err != nil {
+		return fmt.Errorf("expected server certificate with SAN %v: %v", san, err)
+	}
+
+	return nil
+}
+
+func (f *feature) theServerCertificateIsIssuedByTheGeneratedCA() error {
+	if f.ca == nil {
+		return fmt.Errorf("scenario without generated CA")
+	}
+
+	cert, err := f.serverCertificate()
+	if err != nil {
+		return err
+	}
+
+	roots := x509.NewCertPool()
+	roots.AddCert(f.ca.Certificate)
+
+	_, err = cert.Verify(x509.VerifyOptions{
+		Roots: roots,
+	})
+	if err != nil {
+		return fmt.Errorf("expected server certificate issued by %v but the issuer is %v: %v",
+			f.ca.Certificate.Subject, cert.Issuer, err)
+	}
+
+	return nil
+}
+
+func (f *feature) eventuallySendHTTPSRequestWithMethodUntilTheTLSCertificateIsValidFor(method, host string) error {
+	return f.state.Eventually(func() (*http.Request, error) {
+		return http.NewRequest(method, utils.IngressURL("https", f.state.Address, f.state.RequestPath), nil)
+	}, f.certificateIsValidFor(host))
+}
+
+// certificateIsValidFor checks the certificate of the last TLS connection contains
+// the SAN of the host and is issued by the generated CA. Controllers can serve
+// a default certificate until the Secret of the Ingress is loaded.
+func (f *feature) certificateIsValidFor(host string) tstate.Condition {
+	return func(*tstate.Scenario) error {
+		if err := f.theServerCertificateContainsTheSAN(host); err != nil {
+			return err
+		}
+
+		return f.theServerCertificateIsIssuedByTheGeneratedCA()
+	}
+}
+
+// serverCertificate returns the leaf certificate of the last TLS connection
+func (f *feature) serverCertificate() (*x509.Certificate, error) {
+	if f.state.TLS == nil || len(f.state.TLS.PeerCertificates) == 0 {
+		return nil, fmt.Errorf("the last request did not return a server certificate")
+	}
+
+	return f.state.TLS.PeerCertificates[0], nil
+}
+
+// FeatureContext registers the steps of the feature. Steps
+// defined in the package test/steps are registered by TestSuite.
+func FeatureContext(s *godog.Suite, state *tstate.Scenario) {
+	f := &feature{state: state}
+
+	s.Step(`^a new TLS certificate for host "([^"]*)" stored in secret "([^"]*)"$`, f.aNewTLSCertificateForHostStoredInSecret)
+	s.Step(`^the TLS handshake succeeds$`, f.theTLSHandshakeSucceeds)
+	s.Step(`^the server certificate contains the SAN "([^"]*)"$`, f.theServerCertificateContainsTheSAN)
+	s.Step(`^the server certificate is issued by the generated CA$`, f.theServerCertificateIsIssuedByTheGeneratedCA)
+	s.Step(`^eventually send HTTPS request with method "([^"]*)" until the TLS certificate is valid for "([^"]*)"$`, f.eventuallySendHTTPSRequestWithMethodUntilTheTLSCertificateIsValidFor)
+
+	s.BeforeScenario(func(this *messages.Pickle) {
+		f.ca = nil
+	})
+}
@@ -0,0 +1,135 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package echo contains the definition of the response returned by the
+// conformance echo server (images/echoserver), describing the request
+// received by the backend after it was proxied by the ingress controller.
+package echo
+
+import (
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+// Response describes the HTTP request received by the echo server
+type Response struct {
+	Method     string              `json:"method"`
+	Path       string              `json:"path"`
+	Query      string              `json:"query,omitempty"`
+	Host       string              `json:"host"`
+	Proto      string              `json:"proto"`
+	Headers    map[string][]string `json:"headers"`
+	RemoteAddr string              `json:"remoteAddr"`
+	TLS        *TLS                `json:"tls,omitempty"`
+
+	// Backend identifies the echo server instance
+	Backend Backend `json:"backend"`
+}
+
+// TLS contains information about the TLS connection received by the echo server
+type TLS struct {
+	Version            string `json:"version"`
+	ServerName         string `json:"serverName"`
+	CipherSuite        string `json:"cipherSuite"`
+	NegotiatedProtocol string `json:"negotiatedProtocol,omitempty"`
+}
+
+// Backend identifies the echo server instance. Values are
+// configured using environment variables (downward API).
+type Backend struct {
+	Namespace string `json:"namespace,omitempty"`
+	Pod       string `json:"pod,omitempty"`
+	Service   string `json:"service,omitempty"`
+}
+
+// NewResponse returns the description of a request
+func NewResponse(r *http.Request, backend Backend) *Response {
+	resp := &Response{
+		Method:     r.Method,
+		Path:       r.URL.Path,
+		Query:      r.URL.RawQuery,
+		Host:       r.Host,
+		Proto:      r.Proto,
+		Headers:    r.Header.Clone(),
+		RemoteAddr: r.RemoteAddr,
+		Backend:    backend,
+	}
+
+	if r.TLS != nil {
+		resp.TLS = &TLS{
+			Version:            tlsVersion(r.TLS.Version),
+			ServerName:         r.TLS.ServerName,
+			CipherSuite:        tls.CipherSuiteName(r.TLS.CipherSuite),
+			NegotiatedProtocol: r.TLS.NegotiatedProtocol,
+		}
+	}
+
+	return resp
+}
+
+// Handler returns an http.Handler that replies to any request with a
+// JSON document describing the request.
+func Handler(backend Backend) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := json.Marshal(NewResponse(r, backend))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write(body)
+	})
+}
+
+// Parse decodes the body returned by the echo server
+func Parse(body []byte) (*Response, error) {
+	resp := &Response{}
+	if err := json.Unmarshal(body, resp); err != nil {
+		return nil, fmt.Errorf("the response body is not a valid echo server response: %v", err)
+	}
+
+	return resp, nil
+}
+
+// Header returns the first value associated with the header (case insensitive)
+// and true if the header was received by the backend.
+func (r *Response) Header(name string) (string, bool) {
+	values, ok := http.Header(r.Headers)[http.CanonicalHeaderKey(name)]
+	if !ok || len(values) == 0 {
+		return "", ok
+	}
+
+	return values[0], true
+}
+
+func tlsVersion(version uint16) string {
+	switch version {
+	case tls.VersionTLS10:
+		return "TLS 1.0"
+	case tls.VersionTLS11:
+		return "TLS 1.1"
+	case tls.VersionTLS12:
+		return "TLS 1.2"
+	case tls.VersionTLS13:
+		return "TLS 1.3"
+	default:
+		return fmt.Sprintf("unknown (0x%x)", version)
+	}
+}
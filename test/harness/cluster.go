@@ -0,0 +1,466 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package harness provides a fake Kubernetes cluster running the reference
+// ingress controller, allowing the steps and features of the suite to run offline.
+package harness
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+
+	appsv1 "k8s.io/api/apps/v1"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	networkingv1beta1 "k8s.io/api/networking/v1beta1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/rand"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/kubernetes/scheme"
+	k8stesting "k8s.io/client-go/testing"
+
+	"github.com/aledbf/ingress-conformance-bdd/test/reference"
+	"github.com/aledbf/ingress-conformance-bdd/test/utils"
+)
+
+// Cluster is a fake Kubernetes cluster. Objects are stored in memory and
+// the cluster simulates the behavior of the controllers required by the
+// suite: workloads are ready once created, services have endpoints and
+// Ingresses of the class used by the suite are exposed by the reference
+// ingress controller.
+type Cluster struct {
+	// Client is the fake clientset used by the suite
+	Client *fake.Clientset
+	// Objects applies manifests using the fake clientset
+	Objects *utils.ObjectClient
+	// Controller is the reference ingress controller, started by Install
+	Controller *reference.Controller
+}
+
+// serverResources contains the resources served by the fake cluster
+var serverResources = []*metav1.APIResourceList{
+	{
+		GroupVersion: corev1.SchemeGroupVersion.String(),
+		APIResources: []metav1.APIResource{
+			{Name: "namespaces", Kind: "Namespace", Namespaced: false},
+			{Name: "nodes", Kind: "Node", Namespaced: false},
+			{Name: "configmaps", Kind: "ConfigMap", Namespaced: true},
+			{Name: "endpoints", Kind: "Endpoints", Namespaced: true},
+			{Name: "pods", Kind: "Pod", Namespaced: true},
+			{Name: "replicationcontrollers", Kind: "ReplicationController", Namespaced: true},
+			{Name: "secrets", Kind: "Secret", Namespaced: true},
+			{Name: "services", Kind: "Service", Namespaced: true},
+		},
+	},
+	{
+		GroupVersion: appsv1.SchemeGroupVersion.String(),
+		APIResources: []metav1.APIResource{
+			{Name: "deployments", Kind: "Deployment", Namespaced: true},
+		},
+	},
+	{
+		GroupVersion: networkingv1.SchemeGroupVersion.String(),
+		APIResources: []metav1.APIResource{
+			{Name: "ingresses", Kind: "Ingress", Namespaced: true},
+			{Name: "ingressclasses", Kind: "IngressClass", Namespaced: false},
+		},
+	},
+	{
+		GroupVersion: networkingv1beta1.SchemeGroupVersion.String(),
+		APIResources: []metav1.APIResource{
+			{Name: "ingresses", Kind: "Ingress", Namespaced: true},
+			{Name: "ingressclasses", Kind: "IngressClass", Namespaced: false},
+		},
+	},
+}
+
+// NewCluster returns a fake cluster
+func NewCluster() *Cluster {
+	c := fake.NewSimpleClientset()
+	c.Resources = serverResources
+
+	cluster := &Cluster{
+		Client: c,
+	}
+
+	c.PrependReactor("create", "namespaces", generateName)
+	c.PrependReactor("delete", "namespaces", cluster.deleteNamespaceContent)
+	c.PrependReactor("create", "selfsubjectaccessreviews", allowAccess)
+	c.PrependReactor("*", "deployments", deploymentAvailable)
+	c.PrependReactor("*", "replicationcontrollers", replicationControllerReady)
+	c.PrependReactor("create", "services", cluster.serviceEndpoints)
+	c.PrependReactor("create", "ingresses", validateIngress)
+	c.PrependReactor("update", "ingresses", validateIngress)
+	c.PrependReactor("create", "ingresses", cluster.defaultIngressClass)
+
+	dc := dynamicfake.NewSimpleDynamicClient(runtime.NewScheme())
+	dc.PrependReactor("patch", "*", cluster.apply)
+
+	cluster.Objects = utils.NewObjectClientFromClients(c, dc)
+
+	return cluster
+}
+
+// Install configures the suite to use the fake cluster and the manifests
+// and starts the reference ingress controller using the class of the suite
+func (c *Cluster) Install(manifests fs.FS) error {
+	if _, err := utils.DetectIngressAPIVersion(c.Client.Discovery()); err != nil {
+		return err
+	}
+
+	controller, err := reference.New(c.Client, reference.Options{
+		Class:      utils.IngressClassValue,
+		Controller: utils.IngressControllerName,
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := controller.Start(); err != nil {
+		return err
+	}
+
+	c.Controller = controller
+
+	// the status of the Ingresses contains the IP of the controller
+	utils.IngressHTTPPort = controller.Port()
+	utils.IngressHTTPSPort = controller.Port()
+
+	utils.KubeClient = c.Client
+	utils.ObjectsClient = c.Objects
+	utils.Manifests = manifests
+
+	return nil
+}
+
+// Close stops the reference ingress controller
+func (c *Cluster) Close() {
+	if c.Controller != nil {
+		c.Controller.Stop()
+	}
+}
+
+// apply implements server-side apply in the dynamic client, creating or
+// updating the typed object using the fake clientset. This allows the
+// steps to read the objects applied from manifests.
+func (c *Cluster) apply(action k8stesting.Action) (bool, runtime.Object, error) {
+	patch := action.(k8stesting.PatchAction)
+	if patch.GetPatchType() != types.ApplyPatchType {
+		return false, nil, nil
+	}
+
+	obj := &unstructured.Unstructured{}
+	if err := json.Unmarshal(patch.GetPatch(), &obj.Object); err != nil {
+		return true, nil, err
+	}
+
+	typed, err := scheme.Scheme.New(obj.GroupVersionKind())
+	if err != nil {
+		return true, nil, fmt.Errorf("kind %v is not supported by the fake cluster: %v", obj.GroupVersionKind(), err)
+	}
+
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(obj.Object, typed); err != nil {
+		return true, nil, err
+	}
+
+	resource := patch.GetResource()
+	namespace := patch.GetNamespace()
+
+	var result runtime.Object
+
+	_, err = c.Client.Tracker().Get(resource, namespace, patch.GetName())
+	switch {
+	case apierrors.IsNotFound(err):
+		result, err = c.Client.Invokes(k8stesting.NewCreateAction(resource, namespace, typed), typed)
+	case err == nil:
+		result, err = c.Client.Invokes(k8stesting.NewUpdateAction(resource, namespace, typed), typed)
+	}
+
+	if err != nil {
+		return true, nil, err
+	}
+
+	content, err := runtime.DefaultUnstructuredConverter.ToUnstructured(result)
+	if err != nil {
+		return true, nil, err
+	}
+
+	applied := &unstructured.Unstructured{Object: content}
+	applied.SetGroupVersionKind(obj.GroupVersionKind())
+
+	return true, applied, nil
+}
+
+// generateName simulates the generation of names of namespaces
+func generateName(action k8stesting.Action) (bool, runtime.Object, error) {
+	ns := action.(k8stesting.CreateAction).GetObject().(*corev1.Namespace)
+	if ns.Name == "" && ns.GenerateName != "" {
+		ns.Name = ns.GenerateName + rand.String(5)
+	}
+
+	return false, nil, nil
+}
+
+// deleteNamespaceContent removes the objects located in a deleted namespace
+func (c *Cluster) deleteNamespaceContent(action k8stesting.Action) (bool, runtime.Object, error) {
+	namespace := action.(k8stesting.DeleteAction).GetName()
+
+	for _, list := range serverResources {
+		gv, err := schema.ParseGroupVersion(list.GroupVersion)
+		if err != nil {
+			return true, nil, err
+		}
+
+		for _, resource := range list.APIResources {
+			if !resource.Namespaced {
+				continue
+			}
+
+			objects, err := c.Client.Tracker().List(gv.WithResource(resource.Name), gv.WithKind(resource.Kind), namespace)
+			if err != nil {
+				return true, nil, err
+			}
+
+			items, err := meta.ExtractList(objects)
+			if err != nil {
+				return true, nil, err
+			}
+
+			for _, item := range items {
+				obj, err := meta.Accessor(item)
+				if err != nil {
+					return true, nil, err
+				}
+
+				err = c.Client.Tracker().Delete(gv.WithResource(resource.Name), namespace, obj.GetName())
+				if err != nil && !apierrors.IsNotFound(err) {
+					return true, nil, err
+				}
+			}
+		}
+	}
+
+	return false, nil, nil
+}
+
+// allowAccess allows any request reviewed using SelfSubjectAccessReview
+func allowAccess(action k8stesting.Action) (bool, runtime.Object, error) {
+	review := action.(k8stesting.CreateAction).GetObject().(*authorizationv1.SelfSubjectAccessReview)
+	review.Status.Allowed = true
+
+	return true, review, nil
+}
+
+// deploymentAvailable marks all the replicas of created or updated deployments as available
+func deploymentAvailable(action k8stesting.Action) (bool, runtime.Object, error) {
+	d, ok := objectOf(action).(*appsv1.Deployment)
+	if !ok {
+		return false, nil, nil
+	}
+
+	replicas := int32(1)
+	if d.Spec.Replicas != nil {
+		replicas = *d.Spec.Replicas
+	}
+
+	d.Status.ObservedGeneration = d.Generation
+	d.Status.Replicas = replicas
+	d.Status.ReadyReplicas = replicas
+	d.Status.AvailableReplicas = replicas
+
+	return false, nil, nil
+}
+
+// replicationControllerReady marks all the replicas of created or updated replication controllers as ready
+func replicationControllerReady(action k8stesting.Action) (bool, runtime.Object, error) {
+	rc, ok := objectOf(action).(*corev1.ReplicationController)
+	if !ok {
+		return false, nil, nil
+	}
+
+	replicas := int32(1)
+	if rc.Spec.Replicas != nil {
+		replicas = *rc.Spec.Replicas
+	}
+
+	rc.Status.Replicas = replicas
+	rc.Status.ReadyReplicas = replicas
+	rc.Status.AvailableReplicas = replicas
+
+	return false, nil, nil
+}
+
+// serviceEndpoints creates the endpoints of services with a selector
+func (c *Cluster) serviceEndpoints(action k8stesting.Action) (bool, runtime.Object, error) {
+	svc := action.(k8stesting.CreateAction).GetObject().(*corev1.Service)
+	if len(svc.Spec.Selector) == 0 {
+		return false, nil, nil
+	}
+
+	namespace := action.GetNamespace()
+
+	endpoints := &corev1.Endpoints{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      svc.Name,
+			Namespace: namespace,
+		},
+		Subsets: []corev1.EndpointSubset{
+			{
+				Addresses: []corev1.EndpointAddress{{IP: "127.0.0.1"}},
+			},
+		},
+	}
+
+	for _, port := range svc.Spec.Ports {
+		endpoints.Subsets[0].Ports = append(endpoints.Subsets[0].Ports, corev1.EndpointPort{
+			Name:     port.Name,
+			Port:     port.TargetPort.IntVal,
+			Protocol: port.Protocol,
+		})
+	}
+
+	err := c.Client.Tracker().Add(endpoints)
+	if err != nil && !apierrors.IsAlreadyExists(err) {
+		return true, nil, err
+	}
+
+	return false, nil, nil
+}
+
+// validateIngress rejects Ingresses with rules using a backend without service
+// name, returning the same error returned by the API server
+func validateIngress(action k8stesting.Action) (bool, runtime.Object, error) {
+	var ing *networkingv1.Ingress
+
+	switch obj := objectOf(action).(type) {
+	case *networkingv1.Ingress:
+		ing = obj
+	case *networkingv1beta1.Ingress:
+		ing = utils.IngressFromV1beta1(obj)
+	default:
+		return false, nil, nil
+	}
+
+	var errs field.ErrorList
+
+	for i, rule := range ing.Spec.Rules {
+		if rule.HTTP == nil {
+			continue
+		}
+
+		for j, p := range rule.HTTP.Paths {
+			if p.Backend.Resource != nil || (p.Backend.Service != nil && p.Backend.Service.Name != "") {
+				continue
+			}
+
+			path := field.NewPath("spec", "rules").Index(i).Child("http", "paths").Index(j).Child("backend")
+			if action.GetResource().Version == networkingv1.SchemeGroupVersion.Version {
+				path = path.Child("service", "name")
+			} else {
+				path = path.Child("serviceName")
+			}
+
+			errs = append(errs, field.Required(path, ""))
+		}
+	}
+
+	if len(errs) == 0 {
+		return false, nil, nil
+	}
+
+	return true, nil, apierrors.NewInvalid(schema.GroupKind{Group: networkingv1.GroupName, Kind: "Ingress"}, ing.Name, errs)
+}
+
+// defaultIngressClass assigns the default IngressClass to Ingresses created
+// without class, simulating the admission plugin DefaultIngressClass
+func (c *Cluster) defaultIngressClass(action k8stesting.Action) (bool, runtime.Object, error) {
+	var (
+		objMeta   *metav1.ObjectMeta
+		className **string
+	)
+
+	switch obj := objectOf(action).(type) {
+	case *networkingv1.Ingress:
+		objMeta, className = &obj.ObjectMeta, &obj.Spec.IngressClassName
+	case *networkingv1beta1.Ingress:
+		objMeta, className = &obj.ObjectMeta, &obj.Spec.IngressClassName
+	default:
+		return false, nil, nil
+	}
+
+	if *className != nil || objMeta.Annotations[utils.IngressClassKey] != "" {
+		return false, nil, nil
+	}
+
+	// the client cannot be used in a reactor, the IngressClasses are read from the tracker
+	gv := action.GetResource().GroupVersion()
+
+	objects, err := c.Client.Tracker().List(gv.WithResource("ingressclasses"), gv.WithKind("IngressClass"), "")
+	if err != nil {
+		return true, nil, err
+	}
+
+	items, err := meta.ExtractList(objects)
+	if err != nil {
+		return true, nil, err
+	}
+
+	var defaults []string
+
+	for _, item := range items {
+		obj, err := meta.Accessor(item)
+		if err != nil {
+			return true, nil, err
+		}
+
+		if obj.GetAnnotations()[utils.IngressClassDefaultAnnotation] == "true" {
+			defaults = append(defaults, obj.GetName())
+		}
+	}
+
+	switch len(defaults) {
+	case 0:
+		return false, nil, nil
+	case 1:
+		*className = &defaults[0]
+		return false, nil, nil
+	}
+
+	return true, nil, apierrors.NewForbidden(action.GetResource().GroupResource(), objMeta.Name,
+		fmt.Errorf("%d default IngressClasses were found, only 1 allowed", len(defaults)))
+}
+
+// objectOf returns the object of create and update actions
+func objectOf(action k8stesting.Action) runtime.Object {
+	switch a := action.(type) {
+	case k8stesting.CreateAction:
+		return a.GetObject()
+	case k8stesting.UpdateAction:
+		return a.GetObject()
+	default:
+		return nil
+	}
+}
@@ -0,0 +1,154 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package harness
+
+import (
+	"bytes"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/cucumber/godog"
+
+	"github.com/aledbf/ingress-conformance-bdd/test/conformance"
+	tstate "github.com/aledbf/ingress-conformance-bdd/test/state"
+	"github.com/aledbf/ingress-conformance-bdd/test/steps"
+	"github.com/aledbf/ingress-conformance-bdd/test/utils"
+)
+
+// offlineFeatures contains the features that run using the reference ingress controller
+var offlineFeatures = []string{
+	"*",
+}
+
+func TestFeaturesOffline(t *testing.T) {
+	restoreGlobals(t)
+
+	utils.IngressClassValue = "conformance"
+	utils.IngressWaitInterval = 100 * time.Millisecond
+	utils.IngressControllerName = "ingress-conformance.example.com/reference"
+	tstate.DefaultRetryInterval = 100 * time.Millisecond
+
+	cluster := NewCluster()
+	defer cluster.Close()
+
+	if err := cluster.Install(os.DirFS("../../manifests")); err != nil {
+		t.Fatalf("unexpected error configuring the fake cluster: %v", err)
+	}
+
+	// the IngressClass used by features tagged @ingress-class
+	if err := utils.EnsureIngressClass(cluster.Client); err != nil {
+		t.Fatalf("unexpected error creating the IngressClass: %v", err)
+	}
+
+	features, err := conformance.Select(conformance.Features, offlineFeatures)
+	if err != nil {
+		t.Fatalf("unexpected error selecting features: %v", err)
+	}
+
+	for _, feature := range features {
+		feature := feature
+
+		t.Run(feature.Name(), func(t *testing.T) {
+			// features tagged @ingress-class run using spec.ingressClassName
+			useIngressClass, err := hasTag(feature.Path, ingressClassTag)
+			if err != nil {
+				t.Fatalf("unexpected error reading the tags of the feature: %v", err)
+			}
+
+			utils.UseIngressClass = useIngressClass
+			defer func() {
+				utils.UseIngressClass = false
+			}()
+
+			tags := "~" + ingressClassTag
+			if useIngressClass {
+				tags = ""
+			}
+
+			output := &bytes.Buffer{}
+
+			code := godog.RunWithOptions(feature.Name(), func(s *godog.Suite) {
+				state := tstate.New(nil)
+
+				steps.FeatureContext(s, state)
+				feature.Context(s, state)
+			}, godog.Options{
+				Format:      "pretty",
+				Paths:       []string{"../../" + feature.Path},
+				NoColors:    true,
+				Tags:        tags,
+				Output:      output,
+				Concurrency: 1,
+			})
+
+			if code != 0 {
+				t.Fatalf("feature %v failed:\n%v", feature.Path, output.String())
+			}
+		})
+	}
+}
+
+const ingressClassTag = "@ingress-class"
+
+// hasTag checks if the feature file, or any of its scenarios, contains the tag
+func hasTag(file, tag string) (bool, error) {
+	scenarios, err := conformance.ScenarioTags("../../" + file)
+	if err != nil {
+		return false, err
+	}
+
+	for _, tags := range scenarios {
+		for _, t := range tags {
+			if t == tag {
+				return true, nil
+			}
+		}
+	}
+
+	return false, nil
+}
+
+// restoreGlobals restores the configuration of the suite changed by the
+// test and by Cluster.Install when the test finishes
+func restoreGlobals(t *testing.T) {
+	ingressClassValue := utils.IngressClassValue
+	ingressWaitInterval := utils.IngressWaitInterval
+	ingressAPIVersion := utils.IngressAPIVersion
+	useIngressClass := utils.UseIngressClass
+	ingressControllerName := utils.IngressControllerName
+	ingressHTTPPort := utils.IngressHTTPPort
+	ingressHTTPSPort := utils.IngressHTTPSPort
+	kubeClient := utils.KubeClient
+	objectsClient := utils.ObjectsClient
+	manifests := utils.Manifests
+	retryInterval := tstate.DefaultRetryInterval
+
+	t.Cleanup(func() {
+		utils.IngressClassValue = ingressClassValue
+		utils.IngressWaitInterval = ingressWaitInterval
+		utils.IngressAPIVersion = ingressAPIVersion
+		utils.UseIngressClass = useIngressClass
+		utils.IngressControllerName = ingressControllerName
+		utils.IngressHTTPPort = ingressHTTPPort
+		utils.IngressHTTPSPort = ingressHTTPSPort
+		utils.KubeClient = kubeClient
+		utils.ObjectsClient = objectsClient
+		utils.Manifests = manifests
+		tstate.DefaultRetryInterval = retryInterval
+	})
+}
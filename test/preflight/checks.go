@@ -0,0 +1,465 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package preflight
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/aledbf/ingress-conformance-bdd/test/utils"
+)
+
+const (
+	pollInterval = 2 * time.Second
+)
+
+// SetupChecks returns the checks required to configure the suite: permissions,
+// Ingress API version, the IngressClass used by the suite (if enabled) and
+// the removal of the namespaces of previous runs (deleted if deleteNamespaces is true).
+func SetupChecks(deleteNamespaces bool, timeout time.Duration) []Check {
+	checks := []Check{
+		Permissions(),
+		IngressAPI(),
+	}
+
+	if utils.UseIngressClass {
+		checks = append(checks, IngressClass())
+	}
+
+	return append(checks, NamespacesRemoved(deleteNamespaces, timeout))
+}
+
+// ClusterChecks returns the checks of the state of the cluster and the ingress controller
+func ClusterChecks(manifests fs.FS, timeout time.Duration) []Check {
+	return []Check{
+		NodesReady(),
+		IngressController(timeout),
+		Images(manifests, timeout),
+	}
+}
+
+// Permissions checks the permissions defined in utils.ConformancePolicyRules
+func Permissions() Check {
+	return Check{
+		Name:     "Permissions",
+		Required: true,
+		Run: func(c kubernetes.Interface) (string, error) {
+			missing, err := utils.MissingPermissions(c, utils.ConformancePolicyRules)
+			if err != nil {
+				return "", err
+			}
+
+			if len(missing) == 0 {
+				return "all the permissions required by the suite are granted", nil
+			}
+
+			var sb strings.Builder
+			sb.WriteString("missing permissions (images/conformance/conformance.yaml):")
+			for _, permission := range missing {
+				fmt.Fprintf(&sb, "\n- %v", permission)
+			}
+
+			return "", errors.New(sb.String())
+		},
+	}
+}
+
+// IngressAPI checks the cluster serves the Ingress API, configuring the version used by the suite
+func IngressAPI() Check {
+	return Check{
+		Name:     "Ingress API",
+		Required: true,
+		Run: func(c kubernetes.Interface) (string, error) {
+			versions, err := utils.DetectIngressAPIVersion(c.Discovery())
+			if err != nil {
+				return "", err
+			}
+
+			return fmt.Sprintf("served versions %v (using %v)", strings.Join(versions, ", "), utils.IngressAPIVersion), nil
+		},
+	}
+}
+
+// IngressClass checks the IngressClass used by the suite exists or can be created
+func IngressClass() Check {
+	return Check{
+		Name:     "IngressClass",
+		Required: true,
+		Run: func(c kubernetes.Interface) (string, error) {
+			if err := utils.EnsureIngressClass(c); err != nil {
+				return "", err
+			}
+
+			return fmt.Sprintf("using IngressClass %v (controller %v)", utils.IngressClassValue, utils.IngressControllerName), nil
+		},
+	}
+}
+
+// NodesReady checks all the schedulable nodes are Ready
+func NodesReady() Check {
+	return Check{
+		Name: "Nodes",
+		Run: func(c kubernetes.Interface) (string, error) {
+			nodes, err := c.CoreV1().Nodes().List(context.TODO(), metav1.ListOptions{})
+			if err != nil {
+				return "", fmt.Errorf("unable to list nodes: %v", err)
+			}
+
+			var ready, notReady []string
+			for _, node := range nodes.Items {
+				if node.Spec.Unschedulable {
+					continue
+				}
+
+				if isNodeReady(&node) {
+					ready = append(ready, node.Name)
+				} else {
+					notReady = append(notReady, node.Name)
+				}
+			}
+
+			if len(notReady) != 0 {
+				return "", fmt.Errorf("nodes not ready: %v", strings.Join(notReady, ", "))
+			}
+
+			if len(ready) == 0 {
+				return "", fmt.Errorf("there are no schedulable nodes")
+			}
+
+			return fmt.Sprintf("%v schedulable nodes ready", len(ready)), nil
+		},
+	}
+}
+
+func isNodeReady(node *corev1.Node) bool {
+	for _, condition := range node.Status.Conditions {
+		if condition.Type == corev1.NodeReady {
+			return condition.Status == corev1.ConditionTrue
+		}
+	}
+
+	return false
+}
+
+// NamespacesRemoved checks there are no namespaces of previous runs (ingress-conformance-*),
+// waiting for the removal of the namespaces being deleted. The namespaces are deleted
+// only if remove is true, otherwise the check fails reporting them.
+func NamespacesRemoved(remove bool, timeout time.Duration) Check {
+	return Check{
+		Name: "Namespaces",
+		Run: func(c kubernetes.Interface) (string, error) {
+			namespaces, err := utils.TestNamespaces(c)
+			if err != nil {
+				return "", fmt.Errorf("unable to list namespaces of previous runs: %v", err)
+			}
+
+			active, err := activeNamespaces(c, namespaces)
+			if err != nil {
+				return "", err
+			}
+
+			if len(active) != 0 {
+				if !remove {
+					return "", fmt.Errorf("namespaces of previous runs: %v. Delete them or use the flag --delete-namespaces",
+						strings.Join(active, ", "))
+				}
+
+				if err := utils.CleanupNamespaces(c); err != nil {
+					return "", fmt.Errorf("error deleting namespaces of previous runs: %v", err)
+				}
+			}
+
+			var leftover []string
+
+			err = wait.PollImmediate(pollInterval, timeout, func() (bool, error) {
+				namespaces, err := utils.TestNamespaces(c)
+				if err != nil {
+					return false, err
+				}
+
+				leftover = namespaces
+
+				return len(leftover) == 0, nil
+			})
+
+			if err == wait.ErrWaitTimeout {
+				return "", fmt.Errorf("namespaces of a previous run not removed after %v: %v",
+					timeout, strings.Join(leftover, ", "))
+			}
+
+			if err != nil {
+				return "", err
+			}
+
+			if len(active) != 0 {
+				return fmt.Sprintf("deleted namespaces of previous runs: %v", strings.Join(active, ", ")), nil
+			}
+
+			return "there are no namespaces from previous runs", nil
+		},
+	}
+}
+
+// activeNamespaces returns the namespaces not being deleted
+func activeNamespaces(c kubernetes.Interface, namespaces []string) ([]string, error) {
+	var active []string
+
+	for _, name := range namespaces {
+		namespace, err := c.CoreV1().Namespaces().Get(context.TODO(), name, metav1.GetOptions{})
+		if apierrors.IsNotFound(err) {
+			continue
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("unable to get namespace %v: %v", name, err)
+		}
+
+		if namespace.DeletionTimestamp == nil {
+			active = append(active, name)
+		}
+	}
+
+	return active, nil
+}
+
+// IngressController checks the ingress controller handles the class used by the
+// suite, creating a canary Ingress that must get an address before the timeout.
+func IngressController(timeout time.Duration) Check {
+	return Check{
+		Name: "Ingress controller",
+		Run: func(c kubernetes.Interface) (string, error) {
+			return withNamespace(c, func(namespace string) (string, error) {
+				ing := &networkingv1.Ingress{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "preflight-canary",
+						Namespace: namespace,
+					},
+					Spec: networkingv1.IngressSpec{
+						DefaultBackend: &networkingv1.IngressBackend{
+							Service: &networkingv1.IngressServiceBackend{
+								Name: "preflight-canary",
+								Port: networkingv1.ServiceBackendPort{Number: 80},
+							},
+						},
+					},
+				}
+
+				utils.SetIngressClass(ing)
+
+				if _, err := utils.CreateIngress(c, ing); err != nil {
+					return "", err
+				}
+
+				start := time.Now()
+
+				address, err := utils.WaitForIngressAddress(c, namespace, ing.Name, timeout)
+				if err != nil {
+					return "", fmt.Errorf("the canary Ingress (class %v) did not get an address after %v: %v. "+
+						"Check the ingress controller is running and handles the class",
+						utils.IngressClassValue, timeout, err)
+				}
+
+				return fmt.Sprintf("canary Ingress exposed in %v after %v",
+					address, time.Since(start).Round(time.Second)), nil
+			})
+		},
+	}
+}
+
+// Images checks the images used in the manifests of the scenarios and the
+// echo server can be pulled, creating a pod for each image.
+func Images(manifests fs.FS, timeout time.Duration) Check {
+	return Check{
+		Name: "Images",
+		Run: func(c kubernetes.Interface) (string, error) {
+			images, err := ManifestImages(manifests)
+			if err != nil {
+				return "", err
+			}
+
+			images = sets.NewString(images...).Insert(utils.EchoServerImage).List()
+
+			return withNamespace(c, func(namespace string) (string, error) {
+				for i, image := range images {
+					if err := createImagePod(c, namespace, fmt.Sprintf("image-%v", i), image); err != nil {
+						return "", err
+					}
+				}
+
+				var failures []string
+				for i, image := range images {
+					if err := waitForImagePulled(c, namespace, fmt.Sprintf("image-%v", i), timeout); err != nil {
+						failures = append(failures, fmt.Sprintf("%v: %v", image, err))
+					}
+				}
+
+				if len(failures) != 0 {
+					return "", fmt.Errorf("images not available:\n- %v", strings.Join(failures, "\n- "))
+				}
+
+				return fmt.Sprintf("%v images available", len(images)), nil
+			})
+		},
+	}
+}
+
+// ManifestImages returns the images of the containers defined in
+// the manifests (replication controllers and deployments).
+func ManifestImages(manifests fs.FS) ([]string, error) {
+	images := sets.NewString()
+
+	err := fs.WalkDir(manifests, ".", func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		ext := filepath.Ext(path)
+		if entry.IsDir() || (ext != ".yaml" && ext != ".yml" && ext != ".json") {
+			return nil
+		}
+
+		objects, err := utils.ObjectsFromFile(manifests, path, nil)
+		if err != nil {
+			return err
+		}
+
+		for _, obj := range objects {
+			switch obj.GroupVersionKind().GroupKind() {
+			case schema.GroupKind{Kind: "ReplicationController"}, schema.GroupKind{Group: "apps", Kind: "Deployment"}:
+			default:
+				continue
+			}
+
+			for _, field := range []string{"initContainers", "containers"} {
+				containers, _, err := unstructured.NestedSlice(obj.Object, "spec", "template", "spec", field)
+				if err != nil {
+					return fmt.Errorf("invalid %v in %v: %v", field, path, err)
+				}
+
+				for _, container := range containers {
+					if c, ok := container.(map[string]interface{}); ok {
+						// images rendered from values (i.e. {{.EchoImage}}) are empty
+						if image, ok := c["image"].(string); ok && image != "" {
+							images.Insert(image)
+						}
+					}
+				}
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return images.List(), nil
+}
+
+func createImagePod(c kubernetes.Interface, namespace, name, image string) error {
+	grace := int64(0)
+
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: name,
+		},
+		Spec: corev1.PodSpec{
+			RestartPolicy:                 corev1.RestartPolicyNever,
+			TerminationGracePeriodSeconds: &grace,
+			Containers: []corev1.Container{
+				{
+					Name:            "image",
+					Image:           image,
+					ImagePullPolicy: corev1.PullIfNotPresent,
+				},
+			},
+		},
+	}
+
+	_, err := c.CoreV1().Pods(namespace).Create(context.TODO(), pod, metav1.CreateOptions{})
+	if err != nil {
+		return fmt.Errorf("unable to create pod for image %v: %v", image, err)
+	}
+
+	return nil
+}
+
+// waitForImagePulled waits until the container of the pod is started,
+// failing if the image cannot be pulled.
+func waitForImagePulled(c kubernetes.Interface, namespace, name string, timeout time.Duration) error {
+	var reason string
+
+	err := wait.PollImmediate(pollInterval, timeout, func() (bool, error) {
+		pod, err := c.CoreV1().Pods(namespace).Get(context.TODO(), name, metav1.GetOptions{})
+		if err != nil {
+			return false, err
+		}
+
+		for _, cs := range pod.Status.ContainerStatuses {
+			if cs.State.Running != nil || cs.State.Terminated != nil {
+				return true, nil
+			}
+
+			if cs.State.Waiting == nil {
+				continue
+			}
+
+			reason = cs.State.Waiting.Reason
+			switch reason {
+			case "ErrImagePull", "ImagePullBackOff", "InvalidImageName", "ErrImageNeverPull":
+				return false, fmt.Errorf("%v %v", reason, cs.State.Waiting.Message)
+			}
+		}
+
+		return false, nil
+	})
+
+	if err == wait.ErrWaitTimeout {
+		return fmt.Errorf("container not started after %v (%v)", timeout, reason)
+	}
+
+	return err
+}
+
+// withNamespace runs the function using a temporal namespace
+func withNamespace(c kubernetes.Interface, fn func(namespace string) (string, error)) (string, error) {
+	namespace, err := utils.CreateTestNamespace(c)
+	if err != nil {
+		return "", err
+	}
+
+	defer func() {
+		_ = utils.DeleteKubeNamespace(c, namespace)
+	}()
+
+	return fn(namespace)
+}
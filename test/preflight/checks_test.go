@@ -0,0 +1,611 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package preflight
+
+import (
+	"context"
+	"os"
+	"reflect"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	authorizationv1 "k8s.io/api/authorization/v1"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	networkingv1beta1 "k8s.io/api/networking/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/rand"
+	fakediscovery "k8s.io/client-go/discovery/fake"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+
+	"github.com/aledbf/ingress-conformance-bdd/test/utils"
+)
+
+// restoreGlobals restores the configuration of the suite changed by a test
+func restoreGlobals(t *testing.T) {
+	ingressAPIVersion := utils.IngressAPIVersion
+	ingressClassValue := utils.IngressClassValue
+	useIngressClass := utils.UseIngressClass
+	ingressControllerName := utils.IngressControllerName
+	ingressWaitInterval := utils.IngressWaitInterval
+	echoServerImage := utils.EchoServerImage
+
+	t.Cleanup(func() {
+		utils.IngressAPIVersion = ingressAPIVersion
+		utils.IngressClassValue = ingressClassValue
+		utils.UseIngressClass = useIngressClass
+		utils.IngressControllerName = ingressControllerName
+		utils.IngressWaitInterval = ingressWaitInterval
+		utils.EchoServerImage = echoServerImage
+	})
+}
+
+// newClient returns a fake clientset generating the names of namespaces
+func newClient(objects ...runtime.Object) *fake.Clientset {
+	client := fake.NewSimpleClientset(objects...)
+	client.PrependReactor("create", "namespaces", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		ns := action.(k8stesting.CreateAction).GetObject().(*corev1.Namespace)
+		if ns.Name == "" && ns.GenerateName != "" {
+			ns.Name = ns.GenerateName + rand.String(5)
+		}
+
+		return false, nil, nil
+	})
+
+	return client
+}
+
+func testNamespace(name string, deleted bool) *corev1.Namespace {
+	ns := &corev1.Namespace{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   name,
+			Labels: map[string]string{utils.NamespaceNameLabel: "ingress-conformance"},
+		},
+	}
+
+	if deleted {
+		now := metav1.Now()
+		ns.DeletionTimestamp = &now
+	}
+
+	return ns
+}
+
+func TestSetupChecks(t *testing.T) {
+	restoreGlobals(t)
+
+	testCases := []struct {
+		name            string
+		useIngressClass bool
+		expected        []string
+	}{
+		{
+			name:     "annotation",
+			expected: []string{"Permissions", "Ingress API", "Namespaces"},
+		},
+		{
+			name:            "IngressClass",
+			useIngressClass: true,
+			expected:        []string{"Permissions", "Ingress API", "IngressClass", "Namespaces"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			utils.UseIngressClass = tc.useIngressClass
+
+			var names []string
+			for _, check := range SetupChecks(false, time.Second) {
+				names = append(names, check.Name)
+			}
+
+			if !reflect.DeepEqual(names, tc.expected) {
+				t.Errorf("expected checks %v but got %v", tc.expected, names)
+			}
+		})
+	}
+}
+
+func TestPermissions(t *testing.T) {
+	testCases := []struct {
+		name     string
+		denied   string
+		expected string
+	}{
+		{
+			name:     "all the permissions granted",
+			expected: "all the permissions required by the suite are granted",
+		},
+		{
+			name:     "missing permissions",
+			denied:   "nodes",
+			expected: "missing permissions (images/conformance/conformance.yaml):\n- list nodes",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			client := newClient()
+			client.PrependReactor("create", "selfsubjectaccessreviews", func(action k8stesting.Action) (bool, runtime.Object, error) {
+				review := action.(k8stesting.CreateAction).GetObject().(*authorizationv1.SelfSubjectAccessReview).DeepCopy()
+				review.Status.Allowed = review.Spec.ResourceAttributes.Resource != tc.denied
+
+				return true, review, nil
+			})
+
+			message, err := Permissions().Run(client)
+			if err != nil {
+				message = err.Error()
+			}
+
+			if tc.denied == "" && err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if message != tc.expected {
+				t.Errorf("expected %q but got %q", tc.expected, message)
+			}
+		})
+	}
+}
+
+func TestIngressAPI(t *testing.T) {
+	restoreGlobals(t)
+
+	ingresses := []metav1.APIResource{{Name: "ingresses"}}
+
+	testCases := []struct {
+		name      string
+		resources []*metav1.APIResourceList
+		expected  string
+		version   string
+		expectErr bool
+	}{
+		{
+			name: "networking/v1 and networking/v1beta1",
+			resources: []*metav1.APIResourceList{
+				{GroupVersion: networkingv1.SchemeGroupVersion.String(), APIResources: ingresses},
+				{GroupVersion: networkingv1beta1.SchemeGroupVersion.String(), APIResources: ingresses},
+			},
+			expected: "served versions networking.k8s.io/v1, networking.k8s.io/v1beta1 (using networking.k8s.io/v1)",
+			version:  networkingv1.SchemeGroupVersion.String(),
+		},
+		{
+			name: "Ingress not served",
+			resources: []*metav1.APIResourceList{
+				{GroupVersion: networkingv1.SchemeGroupVersion.String()},
+				{GroupVersion: networkingv1beta1.SchemeGroupVersion.String()},
+			},
+			expectErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			client := newClient()
+			client.Discovery().(*fakediscovery.FakeDiscovery).Resources = tc.resources
+
+			message, err := IngressAPI().Run(client)
+			if tc.expectErr {
+				if err == nil {
+					t.Fatalf("expected an error but got %q", message)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if message != tc.expected {
+				t.Errorf("expected %q but got %q", tc.expected, message)
+			}
+
+			if utils.IngressAPIVersion != tc.version {
+				t.Errorf("expected Ingress API version %v but got %v", tc.version, utils.IngressAPIVersion)
+			}
+		})
+	}
+}
+
+func TestIngressClass(t *testing.T) {
+	restoreGlobals(t)
+
+	utils.UseIngressClass = true
+	utils.IngressClassValue = "conformance"
+	utils.IngressAPIVersion = networkingv1.SchemeGroupVersion.String()
+
+	existing := &networkingv1.IngressClass{
+		ObjectMeta: metav1.ObjectMeta{Name: "conformance"},
+		Spec:       networkingv1.IngressClassSpec{Controller: "example.com/other"},
+	}
+
+	testCases := []struct {
+		name       string
+		controller string
+		objects    []runtime.Object
+		expectErr  bool
+	}{
+		{
+			name:      "without controller",
+			expectErr: true,
+		},
+		{
+			name:       "new IngressClass",
+			controller: "example.com/controller",
+		},
+		{
+			name:       "existing IngressClass of the controller",
+			controller: "example.com/other",
+			objects:    []runtime.Object{existing},
+		},
+		{
+			name:       "existing IngressClass of another controller",
+			controller: "example.com/controller",
+			objects:    []runtime.Object{existing},
+			expectErr:  true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			utils.IngressControllerName = tc.controller
+
+			client := newClient(tc.objects...)
+
+			message, err := IngressClass().Run(client)
+			if tc.expectErr {
+				if err == nil {
+					t.Fatalf("expected an error but got %q", message)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			class, err := client.NetworkingV1().IngressClasses().Get(context.TODO(), "conformance", metav1.GetOptions{})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if class.Spec.Controller != tc.controller {
+				t.Errorf("expected controller %v but got %v", tc.controller, class.Spec.Controller)
+			}
+		})
+	}
+}
+
+func node(name string, unschedulable bool, ready corev1.ConditionStatus) *corev1.Node {
+	return &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Spec:       corev1.NodeSpec{Unschedulable: unschedulable},
+		Status: corev1.NodeStatus{
+			Conditions: []corev1.NodeCondition{
+				{Type: corev1.NodeReady, Status: ready},
+			},
+		},
+	}
+}
+
+func TestNodesReady(t *testing.T) {
+	testCases := []struct {
+		name      string
+		nodes     []runtime.Object
+		expected  string
+		expectErr bool
+	}{
+		{
+			name: "nodes ready",
+			nodes: []runtime.Object{
+				node("node-1", false, corev1.ConditionTrue),
+				node("node-2", false, corev1.ConditionTrue),
+				node("node-3", true, corev1.ConditionFalse),
+			},
+			expected: "2 schedulable nodes ready",
+		},
+		{
+			name: "nodes not ready",
+			nodes: []runtime.Object{
+				node("node-1", false, corev1.ConditionTrue),
+				node("node-2", false, corev1.ConditionFalse),
+				node("node-3", false, corev1.ConditionUnknown),
+			},
+			expected:  "nodes not ready: node-2, node-3",
+			expectErr: true,
+		},
+		{
+			name: "no schedulable nodes",
+			nodes: []runtime.Object{
+				node("node-1", true, corev1.ConditionTrue),
+			},
+			expected:  "there are no schedulable nodes",
+			expectErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			message, err := NodesReady().Run(newClient(tc.nodes...))
+			if (err != nil) != tc.expectErr {
+				t.Fatalf("expected error %v but got %v", tc.expectErr, err)
+			}
+
+			if err != nil {
+				message = err.Error()
+			}
+
+			if message != tc.expected {
+				t.Errorf("expected %q but got %q", tc.expected, message)
+			}
+		})
+	}
+}
+
+func TestNamespacesRemoved(t *testing.T) {
+	podNamespace := os.Getenv(utils.PodNamespaceEnv)
+	defer os.Setenv(utils.PodNamespaceEnv, podNamespace)
+
+	os.Setenv(utils.PodNamespaceEnv, "ingress-conformance-runner")
+
+	other := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "default"}}
+
+	testCases := []struct {
+		name       string
+		remove     bool
+		namespaces []runtime.Object
+		expected   string
+		remaining  []string
+		expectErr  bool
+	}{
+		{
+			name:       "no namespaces of previous runs",
+			namespaces: []runtime.Object{other, testNamespace("ingress-conformance-runner", false)},
+			expected:   "there are no namespaces from previous runs",
+			remaining:  []string{"default", "ingress-conformance-runner"},
+		},
+		{
+			name:       "namespaces of previous runs are reported",
+			namespaces: []runtime.Object{other, testNamespace("ingress-conformance-abc", false), testNamespace("ingress-conformance-def", false)},
+			expected:   "namespaces of previous runs: ingress-conformance-abc, ingress-conformance-def. Delete them or use the flag --delete-namespaces",
+			remaining:  []string{"default", "ingress-conformance-abc", "ingress-conformance-def"},
+			expectErr:  true,
+		},
+		{
+			name:       "namespaces of previous runs are deleted",
+			remove:     true,
+			namespaces: []runtime.Object{other, testNamespace("ingress-conformance-abc", false), testNamespace("ingress-conformance-def", false)},
+			expected:   "deleted namespaces of previous runs: ingress-conformance-abc, ingress-conformance-def",
+			remaining:  []string{"default"},
+		},
+		{
+			name:       "namespaces being deleted are not removed before the timeout",
+			namespaces: []runtime.Object{testNamespace("ingress-conformance-abc", true)},
+			expected:   "namespaces of a previous run not removed after 10ms: ingress-conformance-abc",
+			remaining:  []string{"ingress-conformance-abc"},
+			expectErr:  true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			client := newClient(tc.namespaces...)
+
+			message, err := NamespacesRemoved(tc.remove, 10*time.Millisecond).Run(client)
+			if (err != nil) != tc.expectErr {
+				t.Fatalf("expected error %v but got %v", tc.expectErr, err)
+			}
+
+			if err != nil {
+				message = err.Error()
+			}
+
+			if message != tc.expected {
+				t.Errorf("expected %q but got %q", tc.expected, message)
+			}
+
+			namespaces, err := client.CoreV1().Namespaces().List(context.TODO(), metav1.ListOptions{})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			var remaining []string
+			for _, ns := range namespaces.Items {
+				remaining = append(remaining, ns.Name)
+			}
+
+			if !reflect.DeepEqual(remaining, tc.remaining) {
+				t.Errorf("expected namespaces %v but got %v", tc.remaining, remaining)
+			}
+		})
+	}
+}
+
+func TestIngressController(t *testing.T) {
+	restoreGlobals(t)
+
+	utils.UseIngressClass = false
+	utils.IngressClassValue = "conformance"
+	utils.IngressAPIVersion = networkingv1.SchemeGroupVersion.String()
+	utils.IngressWaitInterval = 10 * time.Millisecond
+
+	testCases := []struct {
+		name      string
+		address   string
+		expected  string
+		expectErr bool
+	}{
+		{
+			name:     "the canary Ingress gets an address",
+			address:  "10.0.0.1",
+			expected: "canary Ingress exposed in 10.0.0.1 after 0s",
+		},
+		{
+			name:      "the canary Ingress does not get an address",
+			expectErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			client := newClient()
+			client.PrependReactor("create", "ingresses", func(action k8stesting.Action) (bool, runtime.Object, error) {
+				ing := action.(k8stesting.CreateAction).GetObject().(*networkingv1.Ingress)
+				if ing.Annotations[utils.IngressClassKey] != "conformance" {
+					t.Errorf("expected the canary Ingress to use the class conformance but got %v", ing.Annotations)
+				}
+
+				if tc.address != "" {
+					ing.Status.LoadBalancer.Ingress = []corev1.LoadBalancerIngress{{IP: tc.address}}
+				}
+
+				return false, nil, nil
+			})
+
+			message, err := IngressController(50 * time.Millisecond).Run(client)
+			if (err != nil) != tc.expectErr {
+				t.Fatalf("expected error %v but got %v", tc.expectErr, err)
+			}
+
+			if err == nil && message != tc.expected {
+				t.Errorf("expected %q but got %q", tc.expected, message)
+			}
+
+			namespaces, err := client.CoreV1().Namespaces().List(context.TODO(), metav1.ListOptions{})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if len(namespaces.Items) != 0 {
+				t.Errorf("expected the namespace of the check to be deleted but got %v", namespaces.Items)
+			}
+		})
+	}
+}
+
+var manifests = fstest.MapFS{
+	"001/rc.yaml": {Data: []byte(`apiVersion: v1
+kind: ReplicationController
+metadata:
+  name: echo
+spec:
+  template:
+    spec:
+      containers:
+      - name: echo
+        image: example.com/echo:v1
+`)},
+	"002/deployment.yaml": {Data: []byte(`apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: echo
+spec:
+  template:
+    spec:
+      initContainers:
+      - name: init
+        image: example.com/init:v1
+      containers:
+      - name: echo
+        image: example.com/echo:v1
+      - name: echo-image
+        image: {{.EchoImage}}
+---
+apiVersion: v1
+kind: Pod
+metadata:
+  name: pod
+spec:
+  containers:
+  - name: pod
+    image: example.com/pod:v1
+`)},
+	"002/README.md": {Data: []byte("not a manifest")},
+}
+
+func TestManifestImages(t *testing.T) {
+	images, err := ManifestImages(manifests)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := []string{"example.com/echo:v1", "example.com/init:v1"}
+	if !reflect.DeepEqual(images, expected) {
+		t.Errorf("expected images %v but got %v", expected, images)
+	}
+}
+
+func TestImages(t *testing.T) {
+	restoreGlobals(t)
+
+	utils.EchoServerImage = "example.com/echoserver:v1"
+
+	testCases := []struct {
+		name      string
+		missing   string
+		expected  string
+		expectErr bool
+	}{
+		{
+			name:     "images available",
+			expected: "3 images available",
+		},
+		{
+			name:      "image not available",
+			missing:   "example.com/init:v1",
+			expected:  "images not available:\n- example.com/init:v1: ErrImagePull not found",
+			expectErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			client := newClient()
+			client.PrependReactor("create", "pods", func(action k8stesting.Action) (bool, runtime.Object, error) {
+				pod := action.(k8stesting.CreateAction).GetObject().(*corev1.Pod)
+
+				state := corev1.ContainerState{Running: &corev1.ContainerStateRunning{}}
+				if pod.Spec.Containers[0].Image == tc.missing {
+					state = corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: "ErrImagePull", Message: "not found"}}
+				}
+
+				pod.Status.ContainerStatuses = []corev1.ContainerStatus{{Name: "image", State: state}}
+
+				return false, nil, nil
+			})
+
+			message, err := Images(manifests, time.Second).Run(client)
+			if (err != nil) != tc.expectErr {
+				t.Fatalf("expected error %v but got %v", tc.expectErr, err)
+			}
+
+			if err != nil {
+				message = err.Error()
+			}
+
+			if message != tc.expected {
+				t.Errorf("expected %q but got %q", tc.expected, message)
+			}
+		})
+	}
+}
@@ -0,0 +1,129 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package preflight contains the verifications run before the conformance
+// suite, ensuring the cluster and the ingress controller are able to run the
+// scenarios and reporting the problems found instead of failing the first
+// scenario after a timeout.
+package preflight
+
+import (
+	"fmt"
+	"strings"
+	"text/tabwriter"
+
+	"k8s.io/client-go/kubernetes"
+)
+
+// Status of a check
+const (
+	StatusPassed  = "OK"
+	StatusFailed  = "FAILED"
+	StatusSkipped = "SKIPPED"
+)
+
+// Check is a verification run before the conformance suite
+type Check struct {
+	Name string
+	// Required checks skip the remaining checks when fail
+	Required bool
+	// Run returns a description of the result or an error if the check failed
+	Run func(c kubernetes.Interface) (string, error)
+}
+
+// Result is the result of a check
+type Result struct {
+	Name    string
+	Status  string
+	Message string
+}
+
+// Report contains the results of the checks
+type Report struct {
+	Results []Result
+}
+
+// Run executes the checks in order. When a required check fails
+// the remaining checks are skipped.
+func Run(c kubernetes.Interface, checks []Check) *Report {
+	report := &Report{}
+
+	var skip bool
+	for _, check := range checks {
+		if skip {
+			report.Results = append(report.Results, Result{
+				Name:    check.Name,
+				Status:  StatusSkipped,
+				Message: "a required check failed",
+			})
+
+			continue
+		}
+
+		message, err := check.Run(c)
+		if err != nil {
+			report.Results = append(report.Results, Result{
+				Name:    check.Name,
+				Status:  StatusFailed,
+				Message: err.Error(),
+			})
+
+			skip = check.Required
+			continue
+		}
+
+		report.Results = append(report.Results, Result{
+			Name:    check.Name,
+			Status:  StatusPassed,
+			Message: message,
+		})
+	}
+
+	return report
+}
+
+// Failed returns true if any check failed
+func (r *Report) Failed() bool {
+	for _, result := range r.Results {
+		if result.Status == StatusFailed {
+			return true
+		}
+	}
+
+	return false
+}
+
+// String returns the results of the checks as a table
+func (r *Report) String() string {
+	var sb strings.Builder
+
+	w := tabwriter.NewWriter(&sb, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "CHECK\tSTATUS\tDETAILS")
+
+	for _, result := range r.Results {
+		// multi-line messages are indented below the check
+		lines := strings.Split(strings.TrimSpace(result.Message), "\n")
+		fmt.Fprintf(w, "%v\t%v\t%v\n", result.Name, result.Status, lines[0])
+
+		for _, line := range lines[1:] {
+			fmt.Fprintf(w, "\t\t%v\n", line)
+		}
+	}
+
+	_ = w.Flush()
+
+	return sb.String()
+}
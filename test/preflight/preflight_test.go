@@ -0,0 +1,123 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package preflight
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func passed(message string) func(kubernetes.Interface) (string, error) {
+	return func(kubernetes.Interface) (string, error) {
+		return message, nil
+	}
+}
+
+func failed(message string) func(kubernetes.Interface) (string, error) {
+	return func(kubernetes.Interface) (string, error) {
+		return "", errors.New(message)
+	}
+}
+
+func TestRun(t *testing.T) {
+	testCases := []struct {
+		name     string
+		checks   []Check
+		expected []Result
+		failed   bool
+	}{
+		{
+			name: "all the checks passed",
+			checks: []Check{
+				{Name: "first", Required: true, Run: passed("first passed")},
+				{Name: "second", Run: passed("second passed")},
+			},
+			expected: []Result{
+				{Name: "first", Status: StatusPassed, Message: "first passed"},
+				{Name: "second", Status: StatusPassed, Message: "second passed"},
+			},
+		},
+		{
+			name: "a failed check does not skip the remaining checks",
+			checks: []Check{
+				{Name: "first", Run: failed("first failed")},
+				{Name: "second", Run: passed("second passed")},
+			},
+			expected: []Result{
+				{Name: "first", Status: StatusFailed, Message: "first failed"},
+				{Name: "second", Status: StatusPassed, Message: "second passed"},
+			},
+			failed: true,
+		},
+		{
+			name: "a failed required check skips the remaining checks",
+			checks: []Check{
+				{Name: "first", Run: passed("first passed")},
+				{Name: "second", Required: true, Run: failed("second failed")},
+				{Name: "third", Required: true, Run: passed("third passed")},
+				{Name: "fourth", Run: passed("fourth passed")},
+			},
+			expected: []Result{
+				{Name: "first", Status: StatusPassed, Message: "first passed"},
+				{Name: "second", Status: StatusFailed, Message: "second failed"},
+				{Name: "third", Status: StatusSkipped, Message: "a required check failed"},
+				{Name: "fourth", Status: StatusSkipped, Message: "a required check failed"},
+			},
+			failed: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			report := Run(fake.NewSimpleClientset(), tc.checks)
+
+			if !reflect.DeepEqual(report.Results, tc.expected) {
+				t.Errorf("expected results %v but got %v", tc.expected, report.Results)
+			}
+
+			if report.Failed() != tc.failed {
+				t.Errorf("expected failed %v but got %v", tc.failed, report.Failed())
+			}
+		})
+	}
+}
+
+func TestReportString(t *testing.T) {
+	report := &Report{
+		Results: []Result{
+			{Name: "Permissions", Status: StatusFailed, Message: "missing permissions:\n- get services\n- list nodes\n"},
+			{Name: "Nodes", Status: StatusSkipped, Message: "a required check failed"},
+			{Name: "Ingress API", Status: StatusPassed, Message: "served versions networking.k8s.io/v1"},
+		},
+	}
+
+	expected := `CHECK        STATUS   DETAILS
+Permissions  FAILED   missing permissions:
+                      - get services
+                      - list nodes
+Nodes        SKIPPED  a required check failed
+Ingress API  OK       served versions networking.k8s.io/v1
+`
+
+	if report.String() != expected {
+		t.Errorf("expected report\n%v\nbut got\n%v", expected, report.String())
+	}
+}
@@ -0,0 +1,115 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package reference
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httputil"
+	"strconv"
+
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	corelisters "k8s.io/client-go/listers/core/v1"
+
+	"github.com/aledbf/ingress-conformance-bdd/test/echo"
+)
+
+// Backends returns the handler that serves the requests sent to a service
+type Backends interface {
+	Handler(service *corev1.Service, backend *networkingv1.IngressServiceBackend) (http.Handler, error)
+}
+
+// EchoBackends serves the requests using an in-process echo server reporting
+// the name of the service. This allows running the controller using a fake
+// API server, where pods are not running.
+type EchoBackends struct{}
+
+// Handler returns an echo server for the service
+func (EchoBackends) Handler(service *corev1.Service, backend *networkingv1.IngressServiceBackend) (http.Handler, error) {
+	handler := echo.Handler(echo.Backend{
+		Namespace: service.Namespace,
+		Pod:       service.Name,
+		Service:   service.Name,
+	})
+
+	// the request is not proxied, set the headers added by a proxy
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+			r.Header.Set("X-Forwarded-For", host)
+		}
+
+		r.Header.Set("X-Forwarded-Proto", forwardedProto(r))
+
+		handler.ServeHTTP(w, r)
+	}), nil
+}
+
+// ProxyBackends proxies the requests to the endpoints of the service
+type ProxyBackends struct {
+	Endpoints corelisters.EndpointsLister
+}
+
+// Handler returns a reverse proxy to the first endpoint of the service port
+func (b ProxyBackends) Handler(service *corev1.Service, backend *networkingv1.IngressServiceBackend) (http.Handler, error) {
+	var servicePort *corev1.ServicePort
+	for i, port := range service.Spec.Ports {
+		if (backend.Port.Name != "" && port.Name == backend.Port.Name) || port.Port == backend.Port.Number {
+			servicePort = &service.Spec.Ports[i]
+			break
+		}
+	}
+
+	if servicePort == nil {
+		return nil, fmt.Errorf("service %v/%v does not define the port %v", service.Namespace, service.Name, backend.Port)
+	}
+
+	endpoints, err := b.Endpoints.Endpoints(service.Namespace).Get(service.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, subset := range endpoints.Subsets {
+		for _, port := range subset.Ports {
+			if port.Name != servicePort.Name || len(subset.Addresses) == 0 {
+				continue
+			}
+
+			target := net.JoinHostPort(subset.Addresses[0].IP, strconv.Itoa(int(port.Port)))
+
+			return &httputil.ReverseProxy{
+				Director: func(r *http.Request) {
+					r.Header.Set("X-Forwarded-Proto", forwardedProto(r))
+					r.URL.Scheme = "http"
+					r.URL.Host = target
+				},
+			}, nil
+		}
+	}
+
+	return nil, fmt.Errorf("service %v/%v does not have endpoints", service.Namespace, service.Name)
+}
+
+// forwardedProto returns the protocol used by the client
+func forwardedProto(r *http.Request) string {
+	if r.TLS != nil {
+		return "https"
+	}
+
+	return "http"
+}
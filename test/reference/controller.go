@@ -0,0 +1,447 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package reference contains a reference ingress controller used to validate
+// the suite. The controller watches the Ingresses of a class using informers,
+// writes its address in the status of the Ingresses and serves HTTP and HTTPS
+// (in the same port) routing the requests as defined in the Ingress spec.
+package reference
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/http"
+	"reflect"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	networkingv1beta1 "k8s.io/api/networking/v1beta1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes"
+	corelisters "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/util/workqueue"
+	"k8s.io/klog"
+
+	"github.com/aledbf/ingress-conformance-bdd/test/utils"
+)
+
+// Options configures the reference controller
+type Options struct {
+	// Class of the Ingresses handled by the controller, using the annotation
+	// kubernetes.io/ingress.class or the field spec.ingressClassName
+	Class string
+	// Controller is the value of spec.controller in the IngressClasses handled by the
+	// controller. When defined, Ingresses referencing an IngressClass are handled if the
+	// class uses the controller, and Ingresses without class are handled if the IngressClass
+	// named Class is the default of the cluster. Otherwise only the name of the class is checked.
+	Controller string
+	// ListenAddress is the address of the HTTP/HTTPS server (127.0.0.1:0 by default)
+	ListenAddress string
+	// Address is the IP or hostname written in the status of the Ingresses (the IP
+	// of the server by default). The status does not include the port of the server.
+	Address string
+	// Backends serves the requests sent to services (EchoBackends by default).
+	// ProxyBackends without a lister uses the endpoints informer of the controller.
+	Backends Backends
+}
+
+// Controller is the reference ingress controller
+type Controller struct {
+	client  kubernetes.Interface
+	options Options
+
+	factory        informers.SharedInformerFactory
+	ingresses      cache.SharedIndexInformer
+	ingressClasses cache.SharedIndexInformer
+	services       corelisters.ServiceLister
+	secrets        corelisters.SecretLister
+	queue          workqueue.RateLimitingInterface
+
+	server      *http.Server
+	listener    net.Listener
+	certificate *tls.Certificate
+
+	stopCh chan struct{}
+}
+
+// New returns a reference controller. Ingresses are read using the
+// version defined in utils.IngressAPIVersion.
+func New(c kubernetes.Interface, options Options) (*Controller, error) {
+	if options.ListenAddress == "" {
+		options.ListenAddress = "127.0.0.1:0"
+	}
+
+	if options.Backends == nil {
+		options.Backends = EchoBackends{}
+	}
+
+	certificate, err := defaultCertificate()
+	if err != nil {
+		return nil, err
+	}
+
+	factory := informers.NewSharedInformerFactory(c, 0)
+
+	// requesting the lister registers the informer started with the factory
+	if proxy, ok := options.Backends.(ProxyBackends); ok && proxy.Endpoints == nil {
+		proxy.Endpoints = factory.Core().V1().Endpoints().Lister()
+		options.Backends = proxy
+	}
+
+	controller := &Controller{
+		client:      c,
+		options:     options,
+		factory:     factory,
+		services:    factory.Core().V1().Services().Lister(),
+		secrets:     factory.Core().V1().Secrets().Lister(),
+		queue:       workqueue.NewRateLimitingQueue(workqueue.DefaultControllerRateLimiter()),
+		certificate: certificate,
+		stopCh:      make(chan struct{}),
+	}
+
+	if utils.IsIngressV1() {
+		controller.ingresses = factory.Networking().V1().Ingresses().Informer()
+		controller.ingressClasses = factory.Networking().V1().IngressClasses().Informer()
+	} else {
+		controller.ingresses = factory.Networking().V1beta1().Ingresses().Informer()
+		controller.ingressClasses = factory.Networking().V1beta1().IngressClasses().Informer()
+	}
+
+	controller.ingresses.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: controller.enqueue,
+		UpdateFunc: func(old, cur interface{}) {
+			controller.enqueue(cur)
+		},
+	})
+
+	// the Ingresses handled depend on the IngressClasses
+	controller.ingressClasses.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: func(interface{}) {
+			controller.enqueueAll()
+		},
+		UpdateFunc: func(old, cur interface{}) {
+			controller.enqueueAll()
+		},
+	})
+
+	return controller, nil
+}
+
+// Start starts the informers, the worker updating the status
+// of the Ingresses and the HTTP/HTTPS server
+func (c *Controller) Start() error {
+	l, err := net.Listen("tcp", c.options.ListenAddress)
+	if err != nil {
+		return err
+	}
+
+	c.listener = l
+	if c.options.Address == "" {
+		c.options.Address = l.Addr().(*net.TCPAddr).IP.String()
+	}
+
+	c.factory.Start(c.stopCh)
+	for informer, synced := range c.factory.WaitForCacheSync(c.stopCh) {
+		if !synced {
+			return fmt.Errorf("unable to sync cache of %v", informer)
+		}
+	}
+
+	go wait.Until(c.worker, time.Second, c.stopCh)
+
+	c.server = newServer(c)
+
+	go func() {
+		err := c.server.Serve(&listener{
+			Listener: l,
+			config:   &tls.Config{GetCertificate: c.getCertificate},
+		})
+		if err != nil && err != http.ErrServerClosed {
+			klog.Errorf("Unexpected error serving requests: %v", err)
+		}
+	}()
+
+	return nil
+}
+
+// Stop stops the server and the informers
+func (c *Controller) Stop() {
+	close(c.stopCh)
+	c.queue.ShutDown()
+
+	if c.server != nil {
+		_ = c.server.Close()
+	}
+}
+
+// Address returns the address written in the status of the Ingresses
+func (c *Controller) Address() string {
+	return c.options.Address
+}
+
+// Port returns the port of the HTTP/HTTPS server
+func (c *Controller) Port() int {
+	return c.listener.Addr().(*net.TCPAddr).Port
+}
+
+// ServeHTTP routes the request to the backend of the matching Ingress rule.
+// Requests not matching any rule, or using a service that does not exist,
+// are served by the default backend of the controller (404). Requests to
+// services without endpoints return 503.
+func (c *Controller) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	route := matchRequest(c.list(), r.Host, r.URL.Path)
+	if route == nil || route.Backend.Service == nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	service, err := c.services.Services(route.Namespace).Get(route.Backend.Service.Name)
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	handler, err := c.options.Backends.Handler(service, route.Backend.Service)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+		return
+	}
+
+	handler.ServeHTTP(w, r)
+}
+
+// list returns the Ingresses handled by the controller
+func (c *Controller) list() []*networkingv1.Ingress {
+	var ingresses []*networkingv1.Ingress
+
+	for _, obj := range c.ingresses.GetStore().List() {
+		ing := toIngress(obj)
+		if ing != nil && c.isOfClass(ing) {
+			ingresses = append(ingresses, ing)
+		}
+	}
+
+	return ingresses
+}
+
+// isOfClass checks if the Ingress uses the class of the controller
+func (c *Controller) isOfClass(ing *networkingv1.Ingress) bool {
+	if ing.Spec.IngressClassName != nil {
+		if c.options.Controller == "" {
+			return *ing.Spec.IngressClassName == c.options.Class
+		}
+
+		class := c.getIngressClass(*ing.Spec.IngressClassName)
+
+		return class != nil && class.Spec.Controller == c.options.Controller
+	}
+
+	if class, ok := ing.Annotations[utils.IngressClassKey]; ok {
+		return class == c.options.Class
+	}
+
+	if c.options.Controller == "" {
+		return false
+	}
+
+	// Ingresses without class use the default IngressClass
+	class := c.getIngressClass(c.options.Class)
+
+	return class != nil && class.Spec.Controller == c.options.Controller && utils.IsDefaultIngressClass(class)
+}
+
+// getIngressClass returns the IngressClass with the name, or nil if it does not exist
+func (c *Controller) getIngressClass(name string) *networkingv1.IngressClass {
+	obj, exists, err := c.ingressClasses.GetStore().GetByKey(name)
+	if err != nil || !exists {
+		return nil
+	}
+
+	return toIngressClass(obj)
+}
+
+// getCertificate returns the certificate of the Ingress tls section matching the server
+// name (SNI). If no Ingress defines a certificate for the host, a self-signed is used.
+func (c *Controller) getCertificate(hello *tls.ClientHelloInfo) (*tls.Certificate, error) {
+	host := normalizeHost(hello.ServerName)
+
+	for _, ing := range c.list() {
+		for _, t := range ing.Spec.TLS {
+			for _, h := range t.Hosts {
+				if matchHost(h, host) < wildcardMatch || t.SecretName == "" {
+					continue
+				}
+
+				secret, err := c.secrets.Secrets(ing.Namespace).Get(t.SecretName)
+				if err != nil {
+					klog.Warningf("Secret %v/%v not found: %v", ing.Namespace, t.SecretName, err)
+					continue
+				}
+
+				cert, err := tls.X509KeyPair(secret.Data[corev1.TLSCertKey], secret.Data[corev1.TLSPrivateKeyKey])
+				if err != nil {
+					klog.Warningf("Invalid certificate in secret %v/%v: %v", ing.Namespace, t.SecretName, err)
+					continue
+				}
+
+				return &cert, nil
+			}
+		}
+	}
+
+	return c.certificate, nil
+}
+
+func (c *Controller) enqueue(obj interface{}) {
+	key, err := cache.MetaNamespaceKeyFunc(obj)
+	if err != nil {
+		klog.Errorf("Unexpected error obtaining key: %v", err)
+		return
+	}
+
+	c.queue.Add(key)
+}
+
+// enqueueAll adds all the Ingresses to the queue
+func (c *Controller) enqueueAll() {
+	for _, obj := range c.ingresses.GetStore().List() {
+		c.enqueue(obj)
+	}
+}
+
+func (c *Controller) worker() {
+	for c.processNextItem() {
+	}
+}
+
+func (c *Controller) processNextItem() bool {
+	key, quit := c.queue.Get()
+	if quit {
+		return false
+	}
+
+	defer c.queue.Done(key)
+
+	if err := c.updateStatus(key.(string)); err != nil {
+		klog.Warningf("Error updating status of Ingress %v: %v", key, err)
+		c.queue.AddRateLimited(key)
+
+		return true
+	}
+
+	c.queue.Forget(key)
+
+	return true
+}
+
+// updateStatus writes the address of the controller in the status of the Ingress
+func (c *Controller) updateStatus(key string) error {
+	obj, exists, err := c.ingresses.GetStore().GetByKey(key)
+	if err != nil || !exists {
+		return err
+	}
+
+	ing := toIngress(obj)
+	if ing == nil || !c.isOfClass(ing) {
+		return nil
+	}
+
+	status := []corev1.LoadBalancerIngress{loadBalancerIngress(c.options.Address)}
+	if reflect.DeepEqual(ing.Status.LoadBalancer.Ingress, status) {
+		return nil
+	}
+
+	if utils.IsIngressV1() {
+		ing = ing.DeepCopy()
+		ing.Status.LoadBalancer.Ingress = status
+		_, err = c.client.NetworkingV1().Ingresses(ing.Namespace).UpdateStatus(context.TODO(), ing, metav1.UpdateOptions{})
+	} else {
+		v1beta1 := utils.IngressToV1beta1(ing)
+		v1beta1.Status.LoadBalancer.Ingress = status
+		_, err = c.client.NetworkingV1beta1().Ingresses(ing.Namespace).UpdateStatus(context.TODO(), v1beta1, metav1.UpdateOptions{})
+	}
+
+	if apierrors.IsNotFound(err) {
+		return nil
+	}
+
+	return err
+}
+
+// loadBalancerIngress returns the status of an address (IP or hostname)
+func loadBalancerIngress(address string) corev1.LoadBalancerIngress {
+	if ip := net.ParseIP(address); ip != nil {
+		return corev1.LoadBalancerIngress{IP: address}
+	}
+
+	return corev1.LoadBalancerIngress{Hostname: address}
+}
+
+// defaultCertificate returns the self-signed certificate used when a host does not define one
+func defaultCertificate() (*tls.Certificate, error) {
+	ca, err := utils.NewCA("ingress-conformance-reference")
+	if err != nil {
+		return nil, err
+	}
+
+	cert, err := utils.NewServerCertificate(ca, "ingress.local")
+	if err != nil {
+		return nil, err
+	}
+
+	certificate, err := tls.X509KeyPair(cert.CertificatePEM, cert.KeyPEM)
+	if err != nil {
+		return nil, err
+	}
+
+	return &certificate, nil
+}
+
+// toIngress returns the networking.k8s.io/v1 representation of an Ingress from the informer
+func toIngress(obj interface{}) *networkingv1.Ingress {
+	switch ing := obj.(type) {
+	case *networkingv1.Ingress:
+		return ing
+	case *networkingv1beta1.Ingress:
+		return utils.IngressFromV1beta1(ing)
+	default:
+		return nil
+	}
+}
+
+// toIngressClass returns the networking.k8s.io/v1 representation of an IngressClass from the informer
+func toIngressClass(obj interface{}) *networkingv1.IngressClass {
+	switch class := obj.(type) {
+	case *networkingv1.IngressClass:
+		return class
+	case *networkingv1beta1.IngressClass:
+		return &networkingv1.IngressClass{
+			ObjectMeta: class.ObjectMeta,
+			Spec: networkingv1.IngressClassSpec{
+				Controller: class.Spec.Controller,
+			},
+		}
+	default:
+		return nil
+	}
+}
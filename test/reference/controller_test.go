@@ -0,0 +1,223 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package reference
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/aledbf/ingress-conformance-bdd/test/utils"
+)
+
+func TestLoadBalancerIngress(t *testing.T) {
+	testCases := []struct {
+		address  string
+		expected corev1.LoadBalancerIngress
+	}{
+		{"127.0.0.1", corev1.LoadBalancerIngress{IP: "127.0.0.1"}},
+		{"::1", corev1.LoadBalancerIngress{IP: "::1"}},
+		{"ingress.example.com", corev1.LoadBalancerIngress{Hostname: "ingress.example.com"}},
+	}
+
+	for _, tc := range testCases {
+		if status := loadBalancerIngress(tc.address); status != tc.expected {
+			t.Errorf("expected status %+v for address %v but got %+v", tc.expected, tc.address, status)
+		}
+	}
+}
+
+func TestControllerAddress(t *testing.T) {
+	controller, err := New(fake.NewSimpleClientset(), Options{Class: "conformance"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := controller.Start(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer controller.Stop()
+
+	if controller.Address() != "127.0.0.1" {
+		t.Errorf("expected the IP of the server as address but got %v", controller.Address())
+	}
+
+	if controller.Port() == 0 {
+		t.Errorf("expected the port of the server")
+	}
+}
+
+func TestProxyBackends(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("upstream"))
+	}))
+	defer upstream.Close()
+
+	u, _ := url.Parse(upstream.URL)
+	port, _ := strconv.Atoi(u.Port())
+
+	service := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{Name: "echo", Namespace: "default"},
+		Spec: corev1.ServiceSpec{
+			Ports: []corev1.ServicePort{{Name: "http", Port: 80}},
+		},
+	}
+
+	endpoints := &corev1.Endpoints{
+		ObjectMeta: metav1.ObjectMeta{Name: "echo", Namespace: "default"},
+		Subsets: []corev1.EndpointSubset{{
+			Addresses: []corev1.EndpointAddress{{IP: u.Hostname()}},
+			Ports:     []corev1.EndpointPort{{Name: "http", Port: int32(port)}},
+		}},
+	}
+
+	c := fake.NewSimpleClientset(service, endpoints)
+
+	controller, err := New(c, Options{Class: "conformance", Backends: ProxyBackends{}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := controller.Start(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer controller.Stop()
+
+	svc, err := c.CoreV1().Services("default").Get(context.TODO(), "echo", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	handler, err := controller.options.Backends.Handler(svc, &networkingv1.IngressServiceBackend{
+		Name: "echo",
+		Port: networkingv1.ServiceBackendPort{Number: 80},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	if rec.Body.String() != "upstream" {
+		t.Errorf("expected the response of the endpoint but got %q", rec.Body.String())
+	}
+}
+
+func TestIsOfClass(t *testing.T) {
+	ingressClass := func(name, controller string, isDefault bool) *networkingv1.IngressClass {
+		class := &networkingv1.IngressClass{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			Spec:       networkingv1.IngressClassSpec{Controller: controller},
+		}
+
+		if isDefault {
+			class.Annotations = map[string]string{utils.IngressClassDefaultAnnotation: "true"}
+		}
+
+		return class
+	}
+
+	className := func(name string) *string {
+		return &name
+	}
+
+	testCases := []struct {
+		name       string
+		controller string
+		classes    []*networkingv1.IngressClass
+		ingress    *networkingv1.Ingress
+		expected   bool
+	}{
+		{
+			name:     "annotation",
+			ingress:  &networkingv1.Ingress{ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{utils.IngressClassKey: "conformance"}}},
+			expected: true,
+		},
+		{
+			name:    "annotation of another class",
+			ingress: &networkingv1.Ingress{ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{utils.IngressClassKey: "other"}}},
+		},
+		{
+			name:     "class name without controller",
+			ingress:  &networkingv1.Ingress{Spec: networkingv1.IngressSpec{IngressClassName: className("conformance")}},
+			expected: true,
+		},
+		{
+			name:    "without class",
+			ingress: &networkingv1.Ingress{},
+		},
+		{
+			name:       "IngressClass of the controller",
+			controller: "example.com/reference",
+			classes:    []*networkingv1.IngressClass{ingressClass("reference", "example.com/reference", false)},
+			ingress:    &networkingv1.Ingress{Spec: networkingv1.IngressSpec{IngressClassName: className("reference")}},
+			expected:   true,
+		},
+		{
+			name:       "IngressClass of another controller",
+			controller: "example.com/reference",
+			classes:    []*networkingv1.IngressClass{ingressClass("conformance", "example.com/other", false)},
+			ingress:    &networkingv1.Ingress{Spec: networkingv1.IngressSpec{IngressClassName: className("conformance")}},
+		},
+		{
+			name:       "IngressClass not found",
+			controller: "example.com/reference",
+			ingress:    &networkingv1.Ingress{Spec: networkingv1.IngressSpec{IngressClassName: className("conformance")}},
+		},
+		{
+			name:       "without class using the default IngressClass",
+			controller: "example.com/reference",
+			classes:    []*networkingv1.IngressClass{ingressClass("conformance", "example.com/reference", true)},
+			ingress:    &networkingv1.Ingress{},
+			expected:   true,
+		},
+		{
+			name:       "without class and the IngressClass is not the default",
+			controller: "example.com/reference",
+			classes:    []*networkingv1.IngressClass{ingressClass("conformance", "example.com/reference", false)},
+			ingress:    &networkingv1.Ingress{},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			controller, err := New(fake.NewSimpleClientset(), Options{Class: "conformance", Controller: tc.controller})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			for _, class := range tc.classes {
+				if err := controller.ingressClasses.GetStore().Add(class); err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+			}
+
+			if ofClass := controller.isOfClass(tc.ingress); ofClass != tc.expected {
+				t.Errorf("expected %v but got %v", tc.expected, ofClass)
+			}
+		})
+	}
+}
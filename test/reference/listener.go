@@ -0,0 +1,162 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package reference
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const (
+	// first byte of a TLS handshake record
+	tlsHandshakeRecord = 0x16
+
+	// maximum time to wait for the first byte of a connection
+	peekTimeout = 10 * time.Second
+)
+
+// newServer returns a server for the connections of listener. The server only
+// sets the TLS state of a request when the accepted connection is a *tls.Conn,
+// so the state of the connections detected as HTTPS is set by the handler.
+func newServer(handler http.Handler) *http.Server {
+	return &http.Server{
+		Handler:     withTLSState(handler),
+		ConnContext: connContext,
+	}
+}
+
+// connContextKey is the key of the connection in the context of a request
+type connContextKey struct{}
+
+func connContext(ctx context.Context, conn net.Conn) context.Context {
+	return context.WithValue(ctx, connContextKey{}, conn)
+}
+
+// withTLSState sets the TLS state of the requests received using HTTPS
+func withTLSState(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if conn, ok := r.Context().Value(connContextKey{}).(*peekedConn); ok && r.TLS == nil {
+			r.TLS = conn.tlsState()
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// listener serves HTTP and HTTPS using the same port. The protocol
+// is detected using the first byte sent by the client.
+type listener struct {
+	net.Listener
+
+	config *tls.Config
+}
+
+// Accept returns the next connection. The protocol is detected on the first
+// read, in the goroutine serving the connection, so a client that does not
+// send data does not delay other connections.
+func (l *listener) Accept() (net.Conn, error) {
+	conn, err := l.Listener.Accept()
+	if err != nil {
+		return nil, err
+	}
+
+	return &peekedConn{Conn: conn, config: l.config}, nil
+}
+
+// peekedConn is a connection terminating TLS if the first byte
+// sent by the client starts a handshake
+type peekedConn struct {
+	net.Conn
+
+	config *tls.Config
+
+	once sync.Once
+	// conn is the plain or TLS connection used after the detection
+	conn net.Conn
+	err  error
+}
+
+// detect reads the first byte of the connection to select the protocol
+func (c *peekedConn) detect() {
+	reader := bufio.NewReader(c.Conn)
+
+	_ = c.Conn.SetReadDeadline(time.Now().Add(peekTimeout))
+	first, err := reader.Peek(1)
+	_ = c.Conn.SetReadDeadline(time.Time{})
+
+	if err != nil {
+		c.err = err
+		return
+	}
+
+	buffered := &bufferedConn{Conn: c.Conn, reader: reader}
+	if first[0] == tlsHandshakeRecord {
+		c.conn = tls.Server(buffered, c.config)
+		return
+	}
+
+	c.conn = buffered
+}
+
+func (c *peekedConn) Read(b []byte) (int, error) {
+	c.once.Do(c.detect)
+	if c.err != nil {
+		return 0, c.err
+	}
+
+	return c.conn.Read(b)
+}
+
+func (c *peekedConn) Write(b []byte) (int, error) {
+	c.once.Do(c.detect)
+	if c.err != nil {
+		return 0, c.err
+	}
+
+	return c.conn.Write(b)
+}
+
+// tlsState returns the state of the TLS connection, or nil if the client uses
+// plain HTTP. The protocol is already detected once a request is read.
+func (c *peekedConn) tlsState() *tls.ConnectionState {
+	c.once.Do(c.detect)
+
+	tlsConn, ok := c.conn.(*tls.Conn)
+	if !ok {
+		return nil
+	}
+
+	state := tlsConn.ConnectionState()
+
+	return &state
+}
+
+// bufferedConn is a connection where the first bytes were read using a buffered reader
+type bufferedConn struct {
+	net.Conn
+
+	reader *bufio.Reader
+}
+
+func (c *bufferedConn) Read(b []byte) (int, error) {
+	return c.reader.Read(b)
+}
@@ -0,0 +1,140 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package reference
+
+import (
+	"crypto/tls"
+	"encoding/json"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/aledbf/ingress-conformance-bdd/test/echo"
+)
+
+// serve starts a server using the listener and returns its address
+func serve(t *testing.T, handler http.Handler) string {
+	certificate, err := defaultCertificate()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	server := newServer(handler)
+	t.Cleanup(func() {
+		server.Close()
+	})
+
+	go func() {
+		_ = server.Serve(&listener{
+			Listener: l,
+			config:   &tls.Config{Certificates: []tls.Certificate{*certificate}},
+		})
+	}()
+
+	return l.Addr().String()
+}
+
+func newTestClient() *http.Client {
+	return &http.Client{
+		Timeout: 2 * time.Second,
+		Transport: &http.Transport{
+			TLSClientConfig: &tls.Config{InsecureSkipVerify: true, ServerName: "foo.bar"}, // nolint:gosec
+		},
+	}
+}
+
+func TestListenerIdleConnection(t *testing.T) {
+	address := serve(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	// a client that connects without sending data
+	idle, err := net.Dial("tcp", address)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer idle.Close()
+
+	client := newTestClient()
+
+	for _, scheme := range []string{"http", "https"} {
+		resp, err := client.Get(scheme + "://" + address)
+		if err != nil {
+			t.Fatalf("expected a %v response while a connection is idle: %v", scheme, err)
+		}
+
+		resp.Body.Close()
+
+		if (resp.TLS != nil) != (scheme == "https") {
+			t.Errorf("expected a %v response but TLS is %v", scheme, resp.TLS != nil)
+		}
+	}
+}
+
+func TestListenerTLSState(t *testing.T) {
+	handler, err := EchoBackends{}.Handler(&corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{Name: "echo", Namespace: "default"},
+	}, &networkingv1.IngressServiceBackend{Name: "echo"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	address := serve(t, handler)
+	client := newTestClient()
+
+	for _, scheme := range []string{"http", "https"} {
+		resp, err := client.Get(scheme + "://" + address)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		response := &echo.Response{}
+		err = json.NewDecoder(resp.Body).Decode(response)
+		resp.Body.Close()
+		if err != nil {
+			t.Fatalf("unexpected error decoding the response: %v", err)
+		}
+
+		if proto := response.Headers["X-Forwarded-Proto"]; len(proto) != 1 || proto[0] != scheme {
+			t.Errorf("expected X-Forwarded-Proto %v but got %v", scheme, proto)
+		}
+
+		if scheme == "http" {
+			if response.TLS != nil {
+				t.Errorf("expected a request without TLS but got %v", response.TLS)
+			}
+
+			continue
+		}
+
+		if response.TLS == nil {
+			t.Fatalf("expected the TLS state of the request")
+		}
+
+		if response.TLS.ServerName != "foo.bar" {
+			t.Errorf("expected server name foo.bar but got %v", response.TLS.ServerName)
+		}
+	}
+}
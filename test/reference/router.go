@@ -0,0 +1,175 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package reference
+
+import (
+	"net"
+	"sort"
+	"strings"
+
+	networkingv1 "k8s.io/api/networking/v1"
+)
+
+// route is the result of matching a request with the rules of the Ingresses
+type route struct {
+	Namespace string
+	Backend   *networkingv1.IngressBackend
+}
+
+// hostMatch is the kind of match between the host of a request and the host of a rule
+type hostMatch int
+
+const (
+	noMatch hostMatch = iota
+	// rules without host match any request
+	anyHostMatch
+	// the host of the request matches a wildcard (*.foo.com)
+	wildcardMatch
+	// the host of the request is equal to the host of the rule
+	preciseMatch
+)
+
+// matchRequest returns the backend of the rule matching the host and path of a request:
+//
+//   - Hosts are case insensitive and the port of the request is ignored. Precise hosts
+//     take precedence over wildcards (covering a single DNS label) and wildcards over
+//     rules without host. Rules of different Ingresses using the same host are merged.
+//   - Exact paths match the path of the request. Prefix (and ImplementationSpecific)
+//     paths match element by element, ignoring a trailing slash in the rule. The longest
+//     path is used and, for paths of the same length, Exact is preferred over Prefix.
+//   - Requests not matching any path use the default backend of the Ingresses, sorted by
+//     namespace and name. Returns nil if no Ingress defines a default backend.
+func matchRequest(ingresses []*networkingv1.Ingress, host, path string) *route {
+	host = normalizeHost(host)
+
+	sorted := append([]*networkingv1.Ingress{}, ingresses...)
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].Namespace != sorted[j].Namespace {
+			return sorted[i].Namespace < sorted[j].Namespace
+		}
+
+		return sorted[i].Name < sorted[j].Name
+	})
+
+	// only the rules using the best host match are considered
+	best := noMatch
+	for _, ing := range sorted {
+		for _, rule := range ing.Spec.Rules {
+			if match := matchHost(rule.Host, host); match > best {
+				best = match
+			}
+		}
+	}
+
+	var (
+		result    *route
+		length    = -1
+		exactPath bool
+	)
+
+	if best != noMatch {
+		for _, ing := range sorted {
+			for _, rule := range ing.Spec.Rules {
+				if rule.HTTP == nil || matchHost(rule.Host, host) != best {
+					continue
+				}
+
+				for i := range rule.HTTP.Paths {
+					p := &rule.HTTP.Paths[i]
+
+					exact := p.PathType != nil && *p.PathType == networkingv1.PathTypeExact
+					rulePath := p.Path
+					if !exact {
+						rulePath = strings.TrimSuffix(rulePath, "/")
+					}
+
+					if !matchPath(rulePath, path, exact) {
+						continue
+					}
+
+					if len(rulePath) < length || (len(rulePath) == length && (exactPath || !exact)) {
+						continue
+					}
+
+					result = &route{Namespace: ing.Namespace, Backend: &p.Backend}
+					length = len(rulePath)
+					exactPath = exact
+				}
+			}
+		}
+	}
+
+	if result != nil {
+		return result
+	}
+
+	for _, ing := range sorted {
+		if ing.Spec.DefaultBackend != nil {
+			return &route{Namespace: ing.Namespace, Backend: ing.Spec.DefaultBackend}
+		}
+	}
+
+	return nil
+}
+
+// normalizeHost removes the port and returns the host in lower case
+func normalizeHost(host string) string {
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+
+	return strings.ToLower(host)
+}
+
+// matchHost returns the kind of match between the host of a rule and a request
+func matchHost(ruleHost, host string) hostMatch {
+	ruleHost = strings.ToLower(ruleHost)
+
+	switch {
+	case ruleHost == "":
+		return anyHostMatch
+	case ruleHost == host:
+		return preciseMatch
+	case strings.HasPrefix(ruleHost, "*."):
+		// the wildcard covers a single DNS label
+		i := strings.Index(host, ".")
+		if i > 0 && host[i:] == ruleHost[1:] {
+			return wildcardMatch
+		}
+	}
+
+	return noMatch
+}
+
+// matchPath checks if the path of a rule matches the path of a request. The
+// path of Prefix rules must not contain a trailing slash (the root is empty).
+func matchPath(rulePath, path string, exact bool) bool {
+	if exact {
+		return rulePath == path
+	}
+
+	if rulePath == "" {
+		return true
+	}
+
+	if !strings.HasPrefix(path, rulePath) {
+		return false
+	}
+
+	// the prefix must end in a path element
+	return len(path) == len(rulePath) || path[len(rulePath)] == '/'
+}
@@ -0,0 +1,134 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package reference
+
+import (
+	"testing"
+
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func backend(name string) networkingv1.IngressBackend {
+	return networkingv1.IngressBackend{
+		Service: &networkingv1.IngressServiceBackend{
+			Name: name,
+			Port: networkingv1.ServiceBackendPort{Number: 80},
+		},
+	}
+}
+
+func ingress(name string, defaultBackend string, rules ...networkingv1.IngressRule) *networkingv1.Ingress {
+	ing := &networkingv1.Ingress{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+		Spec:       networkingv1.IngressSpec{Rules: rules},
+	}
+
+	if defaultBackend != "" {
+		b := backend(defaultBackend)
+		ing.Spec.DefaultBackend = &b
+	}
+
+	return ing
+}
+
+func rule(host string, paths ...networkingv1.HTTPIngressPath) networkingv1.IngressRule {
+	return networkingv1.IngressRule{
+		Host: host,
+		IngressRuleValue: networkingv1.IngressRuleValue{
+			HTTP: &networkingv1.HTTPIngressRuleValue{Paths: paths},
+		},
+	}
+}
+
+func path(pathType networkingv1.PathType, p, service string) networkingv1.HTTPIngressPath {
+	return networkingv1.HTTPIngressPath{
+		Path:     p,
+		PathType: &pathType,
+		Backend:  backend(service),
+	}
+}
+
+func TestMatchRequest(t *testing.T) {
+	exact := networkingv1.PathTypeExact
+	prefix := networkingv1.PathTypePrefix
+
+	ingresses := []*networkingv1.Ingress{
+		ingress("paths", "",
+			rule("foo.bar",
+				path(prefix, "/", "root"),
+				path(prefix, "/aaa/bbb/", "prefix"),
+				path(exact, "/aaa/bbb", "exact"),
+				path(prefix, "/foo", "foo"),
+			),
+		),
+		ingress("hosts", "default",
+			rule("*.wildcard.bar", path(prefix, "/", "wildcard")),
+			rule("precise.wildcard.bar", path(prefix, "/", "precise")),
+			rule("", path(prefix, "/any", "any")),
+		),
+		ingress("merged", "",
+			rule("foo.bar", path(exact, "/merged", "merged")),
+		),
+	}
+
+	testCases := []struct {
+		host    string
+		path    string
+		backend string
+	}{
+		{"foo.bar", "/", "root"},
+		{"foo.bar", "/aaa/bbb", "exact"},
+		{"foo.bar", "/aaa/bbb/", "prefix"},
+		{"foo.bar", "/aaa/bbb/ccc", "prefix"},
+		{"foo.bar", "/aaa/bbbxyz", "root"},
+		{"foo.bar", "/foo", "foo"},
+		{"foo.bar", "/foo/", "foo"},
+		{"foo.bar", "/foobar", "root"},
+		{"foo.bar", "/merged", "merged"},
+		{"FOO.bar:8080", "/foo", "foo"},
+		{"a.wildcard.bar", "/", "wildcard"},
+		{"precise.wildcard.bar", "/", "precise"},
+		{"a.b.wildcard.bar", "/any", "any"},
+		{"wildcard.bar", "/any/path", "any"},
+		{"other", "/", "default"},
+		// rules without host are not used when a rule matches the host
+		{"foo.bar", "/any", "root"},
+	}
+
+	for _, tc := range testCases {
+		route := matchRequest(ingresses, tc.host, tc.path)
+		if route == nil {
+			t.Errorf("%v%v: expected backend %v but no route was returned", tc.host, tc.path, tc.backend)
+			continue
+		}
+
+		if name := route.Backend.Service.Name; name != tc.backend {
+			t.Errorf("%v%v: expected backend %v but %v was returned", tc.host, tc.path, tc.backend, name)
+		}
+	}
+}
+
+func TestMatchRequestWithoutDefaultBackend(t *testing.T) {
+	ingresses := []*networkingv1.Ingress{
+		ingress("test", "", rule("foo.bar", path(networkingv1.PathTypePrefix, "/test", "test"))),
+	}
+
+	if route := matchRequest(ingresses, "foo.bar", "/"); route != nil {
+		t.Errorf("expected no route but backend %v was returned", route.Backend.Service.Name)
+	}
+}
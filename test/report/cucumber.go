@@ -0,0 +1,146 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package report reads the cucumber JSON reports generated by the
+// conformance suite (--format cucumber) and builds a consolidated
+// summary of the results that can be consumed by CI systems.
+package report
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+)
+
+// Status of steps, scenarios and features
+const (
+	StatusPassed  = "passed"
+	StatusFailed  = "failed"
+	StatusSkipped = "skipped"
+)
+
+// Feature is a feature in a cucumber JSON report
+type Feature struct {
+	URI         string    `json:"uri"`
+	ID          string    `json:"id"`
+	Keyword     string    `json:"keyword"`
+	Name        string    `json:"name"`
+	Description string    `json:"description"`
+	Line        int       `json:"line"`
+	Tags        []Tag     `json:"tags,omitempty"`
+	Elements    []Element `json:"elements,omitempty"`
+}
+
+// Element is a scenario in a cucumber JSON report. The tags
+// include the tags defined in the feature.
+type Element struct {
+	ID          string `json:"id"`
+	Keyword     string `json:"keyword"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Line        int    `json:"line"`
+	Type        string `json:"type"`
+	Tags        []Tag  `json:"tags,omitempty"`
+	Steps       []Step `json:"steps,omitempty"`
+}
+
+// Tag is a tag in a cucumber JSON report
+type Tag struct {
+	Name string `json:"name"`
+	Line int    `json:"line"`
+}
+
+// Step is a step in a cucumber JSON report
+type Step struct {
+	Keyword   string         `json:"keyword"`
+	Name      string         `json:"name"`
+	Line      int            `json:"line"`
+	Result    Result         `json:"result"`
+	DataTable []DataTableRow `json:"rows,omitempty"`
+}
+
+// DataTableRow is a row of the table argument of a step
+type DataTableRow struct {
+	Cells []string `json:"cells"`
+}
+
+// Result is the result of a step. The duration is in nanoseconds.
+type Result struct {
+	Status   string `json:"status"`
+	Error    string `json:"error_message,omitempty"`
+	Duration *int64 `json:"duration,omitempty"`
+}
+
+// ParseFiles reads cucumber JSON reports returning the features sorted by URI.
+// Features reported in multiple files (i.e. scenarios running in parallel)
+// are merged, sorting the scenarios by line.
+func ParseFiles(paths ...string) ([]Feature, error) {
+	var features []Feature
+	byURI := map[string]int{}
+
+	for _, path := range paths {
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("error reading report %v: %v", path, err)
+		}
+
+		var fileFeatures []Feature
+		if err := json.Unmarshal(data, &fileFeatures); err != nil {
+			return nil, fmt.Errorf("report %v is not a valid cucumber JSON report: %v", path, err)
+		}
+
+		for _, feature := range fileFeatures {
+			i, ok := byURI[feature.URI]
+			if !ok {
+				byURI[feature.URI] = len(features)
+				features = append(features, feature)
+
+				continue
+			}
+
+			features[i].Elements = append(features[i].Elements, feature.Elements...)
+		}
+	}
+
+	for _, feature := range features {
+		elements := feature.Elements
+		sort.SliceStable(elements, func(i, j int) bool {
+			return elements[i].Line < elements[j].Line
+		})
+	}
+
+	sort.SliceStable(features, func(i, j int) bool {
+		return features[i].URI < features[j].URI
+	})
+
+	return features, nil
+}
+
+// LoadDirectory reads the cucumber JSON reports (*-report.json) located in a directory
+func LoadDirectory(dir string) ([]Feature, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*-report.json"))
+	if err != nil {
+		return nil, err
+	}
+
+	if len(paths) == 0 {
+		return nil, fmt.Errorf("there are no cucumber JSON reports in directory %v", dir)
+	}
+
+	return ParseFiles(paths...)
+}
@@ -0,0 +1,138 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package report
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+)
+
+func loadFixture(t *testing.T) []Feature {
+	features, err := LoadDirectory("testdata")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	return features
+}
+
+func TestLoadDirectory(t *testing.T) {
+	features := loadFixture(t)
+
+	testCases := []struct {
+		uri   string
+		lines []int
+	}{
+		{"features/host_matching.feature", []int{35, 49}},
+		{"features/path_matching.feature", []int{10, 30}},
+	}
+
+	if len(features) != len(testCases) {
+		t.Fatalf("expected %v features but got %v", len(testCases), len(features))
+	}
+
+	for i, tc := range testCases {
+		feature := features[i]
+		if feature.URI != tc.uri {
+			t.Errorf("expected feature %v but got %v", tc.uri, feature.URI)
+			continue
+		}
+
+		if len(feature.Elements) != len(tc.lines) {
+			t.Errorf("expected %v scenarios in %v but got %v", len(tc.lines), tc.uri, len(feature.Elements))
+			continue
+		}
+
+		for j, line := range tc.lines {
+			if feature.Elements[j].Line != line {
+				t.Errorf("expected scenario %v of %v at line %v but got %v", j, tc.uri, line, feature.Elements[j].Line)
+			}
+		}
+	}
+}
+
+func TestLoadDirectoryWithoutReports(t *testing.T) {
+	if _, err := LoadDirectory(t.TempDir()); err == nil {
+		t.Errorf("expected an error reading a directory without reports")
+	}
+}
+
+func TestParseFiles(t *testing.T) {
+	// features are sorted by URI, independently of the order of the files
+	features, err := ParseFiles(
+		filepath.Join("testdata", "path_matching.feature-10-report.json"),
+		filepath.Join("testdata", "host_matching.feature-report.json"),
+	)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(features) != 2 || features[0].URI != "features/host_matching.feature" {
+		t.Fatalf("expected the features sorted by URI but got %+v", features)
+	}
+
+	step := features[0].Elements[1].Steps[1]
+	if step.Result.Status != StatusFailed || step.Result.Error != "expected 200 but got 404" {
+		t.Errorf("unexpected result of step %q: %+v", step.Name, step.Result)
+	}
+
+	if step.Result.Duration == nil || *step.Result.Duration != 250000000 {
+		t.Errorf("expected the duration of step %q in nanoseconds", step.Name)
+	}
+}
+
+func TestParseFilesMerge(t *testing.T) {
+	// scenarios running in parallel write a report by scenario
+	features, err := ParseFiles(
+		filepath.Join("testdata", "path_matching.feature-30-report.json"),
+		filepath.Join("testdata", "path_matching.feature-10-report.json"),
+	)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(features) != 1 {
+		t.Fatalf("expected the reports of the feature merged but got %v features", len(features))
+	}
+
+	elements := features[0].Elements
+	if len(elements) != 2 || elements[0].Line != 10 || elements[1].Line != 30 {
+		t.Errorf("expected the scenarios of the reports sorted by line but got %+v", elements)
+	}
+}
+
+func TestParseFilesErrors(t *testing.T) {
+	invalid := filepath.Join(t.TempDir(), "invalid-report.json")
+	if err := ioutil.WriteFile(invalid, []byte("{}"), 0644); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	testCases := []struct {
+		name string
+		path string
+	}{
+		{"missing file", filepath.Join(t.TempDir(), "missing-report.json")},
+		{"invalid report", invalid},
+	}
+
+	for _, tc := range testCases {
+		if _, err := ParseFiles(tc.path); err == nil {
+			t.Errorf("%v: expected an error", tc.name)
+		}
+	}
+}
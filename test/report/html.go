@@ -0,0 +1,180 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package report
+
+import (
+	"fmt"
+	"html/template"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// page contains the data used to render the pages of the HTML report
+type page struct {
+	Title   string
+	Created time.Time
+	Summary *Summary
+	Trend   []Run
+
+	// Features contains the features of the report with the name of their pages
+	Features []featurePage
+	// Tags contains the names of the tags, sorted
+	Tags []string
+
+	// Feature is the feature rendered in a feature page
+	Feature *featurePage
+}
+
+type featurePage struct {
+	File      string
+	Summary   FeatureSummary
+	Scenarios []scenarioPage
+}
+
+type scenarioPage struct {
+	Summary ScenarioSummary
+	Steps   []Step
+}
+
+var (
+	templateFuncs = template.FuncMap{
+		"duration":     formatDuration,
+		"stepDuration": formatStepDuration,
+		"percent":      percent,
+		"date":         formatDate,
+	}
+
+	indexTemplate   = template.Must(template.New("index").Funcs(templateFuncs).Parse(layoutTemplate + indexContent))
+	featureTemplate = template.Must(template.New("feature").Funcs(templateFuncs).Parse(layoutTemplate + featureContent))
+)
+
+// WriteHTML renders the features of cucumber JSON reports as a static HTML
+// site in a directory. The site contains an overview of the features
+// (index.html), the results per tag, the trend of previous runs and one
+// page per feature with the steps of each scenario. Pages do not depend
+// on external resources.
+func WriteHTML(dir string, features []Feature, summary *Summary, trend []Run) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("error creating directory %v: %v", dir, err)
+	}
+
+	p := &page{
+		Title:   "Ingress conformance",
+		Created: time.Now(),
+		Summary: summary,
+		Trend:   trend,
+	}
+
+	for tag := range summary.Tags {
+		p.Tags = append(p.Tags, tag)
+	}
+	sort.Strings(p.Tags)
+
+	for i, feature := range features {
+		fp := featurePage{
+			File:    featureFile(i, feature),
+			Summary: summary.Features[i],
+		}
+
+		for j, element := range feature.Elements {
+			fp.Scenarios = append(fp.Scenarios, scenarioPage{
+				Summary: fp.Summary.Scenarios[j],
+				Steps:   element.Steps,
+			})
+		}
+
+		p.Features = append(p.Features, fp)
+	}
+
+	if err := render(filepath.Join(dir, "index.html"), indexTemplate, p); err != nil {
+		return err
+	}
+
+	for i := range p.Features {
+		fp := *p
+		fp.Title = fmt.Sprintf("%v - %v", p.Title, p.Features[i].Summary.Name)
+		fp.Feature = &p.Features[i]
+
+		if err := render(filepath.Join(dir, p.Features[i].File), featureTemplate, &fp); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func render(path string, tmpl *template.Template, data *page) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("error creating file %v: %v", path, err)
+	}
+
+	err = tmpl.Execute(file, data)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+
+	if err != nil {
+		return fmt.Errorf("error rendering file %v: %v", path, err)
+	}
+
+	return nil
+}
+
+// featureFile returns the name of the page of a feature, based on the
+// name of the feature file (features/default_backend.feature -> feature-0-default_backend.html)
+func featureFile(index int, feature Feature) string {
+	name := strings.TrimSuffix(filepath.Base(feature.URI), filepath.Ext(feature.URI))
+	name = strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-', r == '_':
+			return r
+		default:
+			return '-'
+		}
+	}, name)
+
+	return fmt.Sprintf("feature-%v-%v.html", index, name)
+}
+
+func formatDuration(seconds float64) string {
+	return time.Duration(seconds * float64(time.Second)).Round(time.Millisecond).String()
+}
+
+func formatStepDuration(nanoseconds *int64) string {
+	if nanoseconds == nil {
+		return ""
+	}
+
+	return time.Duration(*nanoseconds).Round(time.Millisecond).String()
+}
+
+func formatDate(t time.Time) string {
+	return t.UTC().Format("2006-01-02 15:04:05 MST")
+}
+
+// percent returns the percentage of part in total, used as width of the bars
+func percent(part, total int) string {
+	if total == 0 {
+		return "0"
+	}
+
+	return fmt.Sprintf("%.2f", float64(part)*100/float64(total))
+}
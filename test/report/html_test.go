@@ -0,0 +1,79 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package report
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWriteHTML(t *testing.T) {
+	dir := t.TempDir()
+
+	features := loadFixture(t)
+
+	summary := NewSummary(features)
+	summary.Controller = "example.com/ingress-controller"
+	summary.Unsupported = []UnsupportedScenario{
+		{URI: "features/host_matching.feature", Feature: "Host matching", Name: "A wildcard host", Line: 82},
+	}
+
+	trend := []Run{NewRun("build-1", time.Now(), summary)}
+
+	if err := WriteHTML(dir, features, summary, trend); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	testCases := []struct {
+		file     string
+		contents []string
+	}{
+		{"index.html", []string{
+			"feature-0-host_matching.html",
+			"feature-1-path_matching.html",
+			"example.com/ingress-controller",
+			"Unsupported by profile",
+			"A wildcard host",
+			"build-1",
+		}},
+		{"feature-0-host_matching.html", []string{
+			"Host matching is case insensitive",
+			"expected 200 but got 404",
+		}},
+		{"feature-1-path_matching.html", []string{
+			"Exact paths",
+			"Prefix paths",
+		}},
+	}
+
+	for _, tc := range testCases {
+		data, err := ioutil.ReadFile(filepath.Join(dir, tc.file))
+		if err != nil {
+			t.Errorf("unexpected error reading %v: %v", tc.file, err)
+			continue
+		}
+
+		for _, content := range tc.contents {
+			if !strings.Contains(string(data), content) {
+				t.Errorf("expected %q in %v", content, tc.file)
+			}
+		}
+	}
+}
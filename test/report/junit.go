@@ -0,0 +1,156 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package report
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+)
+
+type junitTestSuites struct {
+	XMLName  xml.Name         `xml:"testsuites"`
+	Name     string           `xml:"name,attr"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Skipped  int              `xml:"skipped,attr"`
+	Time     string           `xml:"time,attr"`
+	Suites   []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name      string          `xml:"name,attr"`
+	Tests     int             `xml:"tests,attr"`
+	Failures  int             `xml:"failures,attr"`
+	Skipped   int             `xml:"skipped,attr"`
+	Time      string          `xml:"time,attr"`
+	TestCases []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Time      string        `xml:"time,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+	Skipped   *junitSkipped `xml:"skipped,omitempty"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Text    string `xml:",chardata"`
+}
+
+type junitSkipped struct {
+	Message string `xml:"message,attr,omitempty"`
+}
+
+// WriteJUnit writes the summary in JUnit XML format. Each feature
+// is a test suite and each scenario a test case.
+func (s *Summary) WriteJUnit(w io.Writer) error {
+	suites := junitTestSuites{
+		Name:     "ingress-conformance",
+		Tests:    s.Counts.Total,
+		Failures: s.Counts.Failed,
+		Skipped:  s.Counts.Skipped,
+		Time:     seconds(s.Duration),
+	}
+
+	// index of the test suite of each feature
+	suiteIndex := map[string]int{}
+
+	for _, feature := range s.Features {
+		suiteIndex[feature.URI] = len(suites.Suites)
+
+		suite := junitTestSuite{
+			Name:     feature.Name,
+			Tests:    feature.Counts.Total,
+			Failures: feature.Counts.Failed,
+			Skipped:  feature.Counts.Skipped,
+			Time:     seconds(feature.Duration),
+		}
+
+		for _, scenario := range feature.Scenarios {
+			testCase := junitTestCase{
+				Name:      scenario.Name,
+				ClassName: feature.URI,
+				Time:      seconds(scenario.Duration),
+			}
+
+			switch scenario.Status {
+			case StatusFailed:
+				testCase.Failure = &junitFailure{
+					Message: scenario.Failure,
+					Type:    StatusFailed,
+					Text:    scenario.Failure,
+				}
+			case StatusSkipped:
+				testCase.Skipped = &junitSkipped{}
+			}
+
+			suite.TestCases = append(suite.TestCases, testCase)
+		}
+
+		suites.Suites = append(suites.Suites, suite)
+	}
+
+	// scenarios not executed are reported as skipped test cases
+	// in the test suite of the feature
+	for _, scenario := range s.Unsupported {
+		i, ok := suiteIndex[scenario.URI]
+		if !ok {
+			i = len(suites.Suites)
+			suiteIndex[scenario.URI] = i
+
+			suites.Suites = append(suites.Suites, junitTestSuite{
+				Name: scenario.Feature,
+				Time: seconds(0),
+			})
+		}
+
+		suites.Tests++
+		suites.Skipped++
+
+		suite := &suites.Suites[i]
+		suite.Tests++
+		suite.Skipped++
+		suite.TestCases = append(suite.TestCases, junitTestCase{
+			Name:      scenario.Name,
+			ClassName: scenario.URI,
+			Time:      seconds(0),
+			Skipped:   &junitSkipped{Message: "unsupported by profile"},
+		})
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+
+	if err := encoder.Encode(suites); err != nil {
+		return err
+	}
+
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+func seconds(duration float64) string {
+	return fmt.Sprintf("%.3f", duration)
+}
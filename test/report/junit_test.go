@@ -0,0 +1,120 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package report
+
+import (
+	"bytes"
+	"encoding/xml"
+	"testing"
+)
+
+func TestWriteJUnit(t *testing.T) {
+	buf := &bytes.Buffer{}
+	if err := NewSummary(loadFixture(t)).WriteJUnit(buf); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	suites := &junitTestSuites{}
+	if err := xml.Unmarshal(buf.Bytes(), suites); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if suites.Tests != 4 || suites.Failures != 1 || suites.Skipped != 1 || suites.Time != "4.750" {
+		t.Errorf("unexpected test suites %v tests, %v failures, %v skipped in %v",
+			suites.Tests, suites.Failures, suites.Skipped, suites.Time)
+	}
+
+	testCases := []struct {
+		suite    int
+		testCase int
+		failure  string
+		skipped  bool
+	}{
+		{0, 0, "", false},
+		{0, 1, "Then the response status code is 200: expected 200 but got 404", false},
+		{1, 0, "", false},
+		{1, 1, "", true},
+	}
+
+	for _, tc := range testCases {
+		testCase := suites.Suites[tc.suite].TestCases[tc.testCase]
+
+		failure := ""
+		if testCase.Failure != nil {
+			failure = testCase.Failure.Message
+		}
+
+		if failure != tc.failure || (testCase.Skipped != nil) != tc.skipped {
+			t.Errorf("unexpected test case %v: failure %q, skipped %v", testCase.Name, failure, testCase.Skipped != nil)
+		}
+	}
+}
+
+func TestWriteJUnitUnsupportedScenarios(t *testing.T) {
+	summary := &Summary{
+		Counts: Counts{Total: 1, Passed: 1},
+		Features: []FeatureSummary{
+			{
+				Name:   "Host matching",
+				URI:    "features/host_matching.feature",
+				Status: StatusPassed,
+				Counts: Counts{Total: 1, Passed: 1},
+				Scenarios: []ScenarioSummary{
+					{Name: "Host matching is case insensitive", Line: 35, Status: StatusPassed},
+				},
+			},
+		},
+		Unsupported: []UnsupportedScenario{
+			{URI: "features/host_matching.feature", Feature: "Host matching", Name: "A wildcard host", Line: 82},
+			{URI: "features/ingress_class.feature", Feature: "Ingress class", Name: "IngressClass", Line: 10},
+		},
+	}
+
+	buf := &bytes.Buffer{}
+	if err := summary.WriteJUnit(buf); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	suites := &junitTestSuites{}
+	if err := xml.Unmarshal(buf.Bytes(), suites); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if suites.Tests != 3 || suites.Skipped != 2 {
+		t.Errorf("expected 3 tests (2 skipped) but got %v (%v skipped)", suites.Tests, suites.Skipped)
+	}
+
+	if len(suites.Suites) != 2 {
+		t.Fatalf("expected a test suite by feature but got %v", len(suites.Suites))
+	}
+
+	// the unsupported scenario is part of the suite of the executed feature
+	hostMatching := suites.Suites[0]
+	if hostMatching.Tests != 2 || hostMatching.Skipped != 1 || len(hostMatching.TestCases) != 2 {
+		t.Errorf("unexpected test suite %+v", hostMatching)
+	}
+
+	skipped := hostMatching.TestCases[1].Skipped
+	if skipped == nil || skipped.Message != "unsupported by profile" {
+		t.Errorf("expected the scenario skipped as unsupported by profile but got %+v", hostMatching.TestCases[1])
+	}
+
+	ingressClass := suites.Suites[1]
+	if ingressClass.Name != "Ingress class" || ingressClass.Tests != 1 || ingressClass.Skipped != 1 {
+		t.Errorf("unexpected test suite %+v", ingressClass)
+	}
+}
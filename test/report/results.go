@@ -0,0 +1,141 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package report
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+const (
+	// ResultsArchive name of the archive containing the files of the results directory
+	ResultsArchive = "e2e.tar.gz"
+	// DoneFile name of the file that signals the results are ready. The
+	// content of the file is the path of the results archive (sonobuoy plugin contract)
+	DoneFile = "done"
+	// ErrorFile name of the file containing the errors that stopped the suite
+	ErrorFile = "error.txt"
+)
+
+// SaveResults archives the content of the results directory in e2e.tar.gz
+// and then writes the done file containing the location of the archive.
+func SaveResults(dir string) error {
+	archive := filepath.Join(dir, ResultsArchive)
+
+	if err := archiveDirectory(dir, archive); err != nil {
+		return fmt.Errorf("error creating archive %v: %v", archive, err)
+	}
+
+	return WriteDone(dir, archive)
+}
+
+// WriteDone writes the done file containing the location of the results
+func WriteDone(dir, results string) error {
+	done := filepath.Join(dir, DoneFile)
+	if err := ioutil.WriteFile(done, []byte(results), 0644); err != nil {
+		return fmt.Errorf("error writing file %v: %v", done, err)
+	}
+
+	return nil
+}
+
+// WriteError appends the error to the error file of the results directory,
+// creating the directory if it does not exist, and returns the path of the file.
+func WriteError(dir string, failure error) (string, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", err
+	}
+
+	path := filepath.Join(dir, ErrorFile)
+
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	if _, err := fmt.Fprintln(file, failure); err != nil {
+		return "", err
+	}
+
+	return path, file.Close()
+}
+
+// archiveDirectory writes the regular files located in dir in a gzip compressed
+// tar file. The archive itself and the done file are excluded.
+func archiveDirectory(dir, archive string) error {
+	file, err := os.Create(archive)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	gw := gzip.NewWriter(file)
+	tw := tar.NewWriter(gw)
+
+	err = filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		name, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+
+		if !info.Mode().IsRegular() || name == ResultsArchive || name == DoneFile {
+			return nil
+		}
+
+		header, err := tar.FileInfoHeader(info, "")
+		if err != nil {
+			return err
+		}
+
+		header.Name = filepath.ToSlash(name)
+		if err := tw.WriteHeader(header); err != nil {
+			return err
+		}
+
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+
+		_, err = io.Copy(tw, f)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := tw.Close(); err != nil {
+		return err
+	}
+
+	if err := gw.Close(); err != nil {
+		return err
+	}
+
+	return file.Close()
+}
@@ -0,0 +1,164 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package report
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestSaveResults(t *testing.T) {
+	dir := t.TempDir()
+
+	files := map[string]string{
+		"summary.json":                      "{}",
+		"junit.xml":                         "<testsuites/>",
+		"reports/host_matching-report.json": "[]",
+	}
+
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	// saving the results again replaces the archive
+	for i := 0; i < 2; i++ {
+		if err := SaveResults(dir); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	archive := filepath.Join(dir, ResultsArchive)
+
+	done, err := ioutil.ReadFile(filepath.Join(dir, DoneFile))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if string(done) != archive {
+		t.Errorf("expected the path %v in the done file but got %v", archive, string(done))
+	}
+
+	archived := readArchive(t, archive)
+
+	var expected []string
+	for name := range files {
+		expected = append(expected, name)
+	}
+
+	sort.Strings(expected)
+
+	var names []string
+	for name, content := range archived {
+		names = append(names, name)
+
+		if content != files[name] {
+			t.Errorf("unexpected content of %v in the archive: %q", name, content)
+		}
+	}
+
+	sort.Strings(names)
+
+	if !reflect.DeepEqual(names, expected) {
+		t.Errorf("expected the files %v in the archive but got %v", expected, names)
+	}
+}
+
+func TestWriteError(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "results")
+
+	for _, failure := range []error{errors.New("preflight failed"), errors.New("error saving results")} {
+		path, err := WriteError(dir, failure)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if path != filepath.Join(dir, ErrorFile) {
+			t.Errorf("unexpected path of the error file %v", path)
+		}
+	}
+
+	content, err := ioutil.ReadFile(filepath.Join(dir, ErrorFile))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if string(content) != "preflight failed\nerror saving results\n" {
+		t.Errorf("expected the errors appended to the file but got %q", string(content))
+	}
+
+	// the error file is part of the results
+	if err := SaveResults(dir); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if _, ok := readArchive(t, filepath.Join(dir, ResultsArchive))[ErrorFile]; !ok {
+		t.Errorf("expected the error file in the archive")
+	}
+}
+
+// readArchive returns the content of the files of a gzip compressed tar file by name
+func readArchive(t *testing.T, path string) map[string]string {
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer file.Close()
+
+	gr, err := gzip.NewReader(file)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	files := map[string]string{}
+
+	tr := tar.NewReader(gr)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		buf := &strings.Builder{}
+		if _, err := io.Copy(buf, tr); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		files[header.Name] = buf.String()
+	}
+
+	return files
+}
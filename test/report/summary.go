@@ -0,0 +1,357 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package report
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/aledbf/ingress-conformance-bdd/test/conformance"
+	"github.com/aledbf/ingress-conformance-bdd/test/conformance/level"
+)
+
+// Counts contains the number of scenarios by status
+type Counts struct {
+	Total   int `json:"total"`
+	Passed  int `json:"passed"`
+	Failed  int `json:"failed"`
+	Skipped int `json:"skipped"`
+}
+
+func (c *Counts) add(status string) {
+	c.Total++
+
+	switch status {
+	case StatusPassed:
+		c.Passed++
+	case StatusFailed:
+		c.Failed++
+	default:
+		c.Skipped++
+	}
+}
+
+// Summary is the consolidated result of a conformance run
+type Summary struct {
+	Status string `json:"status"`
+	// Duration in seconds
+	Duration float64 `json:"duration"`
+
+	// Counts contains the number of scenarios by status
+	Counts   Counts            `json:"counts"`
+	Features []FeatureSummary  `json:"features"`
+	Tags     map[string]Counts `json:"tags"`
+
+	// Level is the highest conformance level (core, extended or
+	// implementation-specific) where all the scenarios passed
+	Level string `json:"level,omitempty"`
+
+	// Controller is the name of the ingress controller defined in the profile
+	Controller string `json:"controller,omitempty"`
+	// Unsupported contains the scenarios not executed because
+	// the profile of the controller does not support them
+	Unsupported []UnsupportedScenario `json:"unsupported,omitempty"`
+}
+
+// UnsupportedScenario is a scenario excluded by the profile of the controller
+type UnsupportedScenario struct {
+	URI     string   `json:"uri"`
+	Feature string   `json:"feature"`
+	Name    string   `json:"name"`
+	Line    int      `json:"line"`
+	Tags    []string `json:"tags,omitempty"`
+}
+
+// FeatureSummary is the result of a feature
+type FeatureSummary struct {
+	Name     string  `json:"name"`
+	URI      string  `json:"uri"`
+	Status   string  `json:"status"`
+	Duration float64 `json:"duration"`
+
+	Tags      []string          `json:"tags,omitempty"`
+	Counts    Counts            `json:"counts"`
+	Scenarios []ScenarioSummary `json:"scenarios"`
+}
+
+// ScenarioSummary is the result of a scenario
+type ScenarioSummary struct {
+	Name     string   `json:"name"`
+	Line     int      `json:"line"`
+	Status   string   `json:"status"`
+	Duration float64  `json:"duration"`
+	Tags     []string `json:"tags,omitempty"`
+
+	// Failure contains the failed step and the error message
+	Failure string `json:"failure,omitempty"`
+}
+
+// NewSummary builds a summary from the features of cucumber JSON reports.
+// A scenario is failed if any step failed and passed if all the steps passed.
+// Otherwise (steps skipped, pending or undefined) the scenario is skipped.
+func NewSummary(features []Feature) *Summary {
+	summary := &Summary{
+		Features: []FeatureSummary{},
+		Tags:     map[string]Counts{},
+	}
+
+	for _, feature := range features {
+		fs := FeatureSummary{
+			Name:      feature.Name,
+			URI:       feature.URI,
+			Tags:      tagNames(feature.Tags),
+			Scenarios: []ScenarioSummary{},
+		}
+
+		for _, element := range feature.Elements {
+			scenario := newScenarioSummary(element)
+
+			fs.Scenarios = append(fs.Scenarios, scenario)
+			fs.Counts.add(scenario.Status)
+			fs.Duration += scenario.Duration
+
+			summary.Counts.add(scenario.Status)
+			for _, tag := range scenario.Tags {
+				counts := summary.Tags[tag]
+				counts.add(scenario.Status)
+				summary.Tags[tag] = counts
+			}
+		}
+
+		fs.Status = status(fs.Counts)
+		summary.Features = append(summary.Features, fs)
+		summary.Duration += fs.Duration
+	}
+
+	summary.Status = status(summary.Counts)
+	summary.Level = levelPassed(summary.Tags)
+
+	return summary
+}
+
+func newScenarioSummary(element Element) ScenarioSummary {
+	scenario := ScenarioSummary{
+		Name:   element.Name,
+		Line:   element.Line,
+		Status: StatusPassed,
+		Tags:   tagNames(element.Tags),
+	}
+
+	if len(element.Steps) == 0 {
+		scenario.Status = StatusSkipped
+	}
+
+	var duration int64
+	for _, step := range element.Steps {
+		if step.Result.Duration != nil {
+			duration += *step.Result.Duration
+		}
+
+		switch step.Result.Status {
+		case StatusPassed:
+		case StatusFailed:
+			scenario.Status = StatusFailed
+			scenario.Failure = fmt.Sprintf("%v%v: %v",
+				step.Keyword, step.Name, step.Result.Error)
+		default:
+			if scenario.Status != StatusFailed {
+				scenario.Status = StatusSkipped
+			}
+		}
+	}
+
+	scenario.Duration = time.Duration(duration).Seconds()
+
+	return scenario
+}
+
+// status returns failed if any scenario failed and passed if at least one
+// scenario passed. Features without scenarios executed are skipped.
+func status(counts Counts) string {
+	switch {
+	case counts.Failed > 0:
+		return StatusFailed
+	case counts.Passed > 0:
+		return StatusPassed
+	default:
+		return StatusSkipped
+	}
+}
+
+// UnsupportedScenarios returns the scenarios of the feature files excluded by
+// the profile. Scenarios excluded by their own tags (i.e. @wildcard-host) are
+// not present in the cucumber reports, even when the rest of the feature runs.
+func UnsupportedScenarios(profile *conformance.Profile, files ...string) ([]UnsupportedScenario, error) {
+	var unsupported []UnsupportedScenario
+
+	for _, file := range files {
+		scenarios, err := conformance.Scenarios(file)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, scenario := range scenarios {
+			if !profile.Excludes(scenario.Tags) {
+				continue
+			}
+
+			tags := make([]Tag, 0, len(scenario.Tags))
+			for _, tag := range scenario.Tags {
+				tags = append(tags, Tag{Name: tag})
+			}
+
+			unsupported = append(unsupported, UnsupportedScenario{
+				URI:     file,
+				Feature: scenario.Feature,
+				Name:    scenario.Name,
+				Line:    scenario.Line,
+				Tags:    tagNames(tags),
+			})
+		}
+	}
+
+	return unsupported, nil
+}
+
+// levelPassed returns the highest conformance level where all the scenarios of
+// the level, and the lower levels, passed. Only the scenarios of the reports are
+// considered (the suite replaces the level including the scenarios not executed).
+func levelPassed(tags map[string]Counts) string {
+	passed := ""
+
+	for _, l := range level.Levels {
+		counts := tags[l.Tag()]
+		if counts.Total == 0 || counts.Passed != counts.Total {
+			break
+		}
+
+		passed = string(l)
+	}
+
+	return passed
+}
+
+// tagNames returns the unique names of the tags, sorted
+func tagNames(tags []Tag) []string {
+	seen := map[string]bool{}
+	names := []string{}
+
+	for _, tag := range tags {
+		if seen[tag.Name] {
+			continue
+		}
+
+		seen[tag.Name] = true
+		names = append(names, tag.Name)
+	}
+
+	sort.Strings(names)
+
+	return names
+}
+
+// LoadSummary reads a summary in JSON format. A missing file returns nil.
+func LoadSummary(path string) (*Summary, error) {
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("error reading summary %v: %v", path, err)
+	}
+
+	summary := &Summary{}
+	if err := json.Unmarshal(data, summary); err != nil {
+		return nil, fmt.Errorf("summary %v is not valid: %v", path, err)
+	}
+
+	return summary, nil
+}
+
+// SetSuiteResults copies the results of the suite not present in the cucumber
+// reports: the controller and unsupported scenarios of the profile and the
+// conformance level (computed by the suite including the features not executed)
+func (s *Summary) SetSuiteResults(suite *Summary) {
+	s.Controller = suite.Controller
+	s.Unsupported = suite.Unsupported
+	s.Level = suite.Level
+}
+
+// WriteJSON writes the summary in JSON format
+func (s *Summary) WriteJSON(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	encoder.SetEscapeHTML(false)
+
+	return encoder.Encode(s)
+}
+
+const (
+	// SummaryFile name of the file containing the summary in JSON format
+	SummaryFile = "summary.json"
+	// JUnitFile name of the file containing the summary in JUnit XML format
+	JUnitFile = "junit.xml"
+)
+
+// WriteFiles writes the summary in JSON and JUnit XML format in a directory
+func (s *Summary) WriteFiles(dir string) error {
+	for name, write := range map[string]func(io.Writer) error{
+		SummaryFile: s.WriteJSON,
+		JUnitFile:   s.WriteJUnit,
+	} {
+		path := filepath.Join(dir, name)
+
+		file, err := os.Create(path)
+		if err != nil {
+			return fmt.Errorf("error creating file %v: %v", path, err)
+		}
+
+		err = write(file)
+		if closeErr := file.Close(); err == nil {
+			err = closeErr
+		}
+
+		if err != nil {
+			return fmt.Errorf("error writing file %v: %v", path, err)
+		}
+	}
+
+	return nil
+}
+
+// String returns a short description of the summary
+func (s *Summary) String() string {
+	description := fmt.Sprintf("%v scenarios (%v passed, %v failed, %v skipped) in %v features",
+		s.Counts.Total, s.Counts.Passed, s.Counts.Failed, s.Counts.Skipped, len(s.Features))
+
+	if len(s.Unsupported) != 0 {
+		description = fmt.Sprintf("%v, %v scenarios unsupported by profile", description, len(s.Unsupported))
+	}
+
+	if s.Level != "" {
+		description = fmt.Sprintf("%v, level %v passed in full", description, s.Level)
+	}
+
+	return description
+}
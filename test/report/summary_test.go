@@ -0,0 +1,176 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package report
+
+import (
+	"bytes"
+	"encoding/json"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/aledbf/ingress-conformance-bdd/test/conformance"
+)
+
+func TestUnsupportedScenarios(t *testing.T) {
+	file := "../../features/host_matching.feature"
+
+	testCases := []struct {
+		profile conformance.Profile
+		lines   []int
+	}{
+		{conformance.Profile{Unsupported: []string{"@tls"}}, nil},
+		// scenario excluded by its own tag, the rest of the feature runs
+		{conformance.Profile{Unsupported: []string{"@wildcard-host"}}, []int{82}},
+		// all the scenarios (and examples) of the feature
+		{conformance.Profile{Unsupported: []string{"@host-matching"}}, []int{19, 35, 49, 64, 82, 99, 99, 99}},
+	}
+
+	for _, tc := range testCases {
+		unsupported, err := UnsupportedScenarios(&tc.profile, file)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		var lines []int
+		for _, scenario := range unsupported {
+			if scenario.URI != file || scenario.Feature == "" {
+				t.Errorf("expected the URI and feature of the scenario but got %+v", scenario)
+			}
+
+			lines = append(lines, scenario.Line)
+		}
+
+		if !reflect.DeepEqual(lines, tc.lines) {
+			t.Errorf("expected unsupported scenarios at lines %v using %+v but got %v", tc.lines, tc.profile, lines)
+		}
+	}
+}
+
+func TestNewSummary(t *testing.T) {
+	summary := NewSummary(loadFixture(t))
+
+	if summary.Status != StatusFailed {
+		t.Errorf("expected status failed but got %v", summary.Status)
+	}
+
+	if expected := (Counts{Total: 4, Passed: 2, Failed: 1, Skipped: 1}); summary.Counts != expected {
+		t.Errorf("expected counts %+v but got %+v", expected, summary.Counts)
+	}
+
+	if summary.Duration != 4.75 {
+		t.Errorf("expected a duration of 4.75s but got %v", summary.Duration)
+	}
+
+	if expected := (Counts{Total: 4, Passed: 2, Failed: 1, Skipped: 1}); summary.Tags["@core"] != expected {
+		t.Errorf("expected counts %+v for tag @core but got %+v", expected, summary.Tags["@core"])
+	}
+
+	// a core scenario failed
+	if summary.Level != "" {
+		t.Errorf("expected no level passed but got %v", summary.Level)
+	}
+
+	testCases := []struct {
+		feature  int
+		scenario int
+		status   string
+		failure  string
+	}{
+		{0, 0, StatusPassed, ""},
+		{0, 1, StatusFailed, "Then the response status code is 200: expected 200 but got 404"},
+		{1, 0, StatusPassed, ""},
+		{1, 1, StatusSkipped, ""},
+	}
+
+	for _, tc := range testCases {
+		scenario := summary.Features[tc.feature].Scenarios[tc.scenario]
+		if scenario.Status != tc.status || scenario.Failure != tc.failure {
+			t.Errorf("expected scenario %v with status %v (%q) but got %v (%q)",
+				scenario.Name, tc.status, tc.failure, scenario.Status, scenario.Failure)
+		}
+	}
+
+	for i, status := range []string{StatusFailed, StatusPassed} {
+		if summary.Features[i].Status != status {
+			t.Errorf("expected feature %v with status %v but got %v", summary.Features[i].Name, status, summary.Features[i].Status)
+		}
+	}
+}
+
+func TestLevelPassed(t *testing.T) {
+	testCases := []struct {
+		tags  map[string]Counts
+		level string
+	}{
+		{map[string]Counts{}, ""},
+		{map[string]Counts{"@core": {Total: 2, Passed: 1, Failed: 1}}, ""},
+		{map[string]Counts{"@core": {Total: 2, Passed: 2}}, "core"},
+		{map[string]Counts{"@core": {Total: 2, Passed: 2}, "@extended": {Total: 1, Skipped: 1}}, "core"},
+		{map[string]Counts{"@core": {Total: 2, Passed: 2}, "@extended": {Total: 1, Passed: 1}}, "extended"},
+	}
+
+	for _, tc := range testCases {
+		if level := levelPassed(tc.tags); level != tc.level {
+			t.Errorf("expected level %q for %+v but got %q", tc.level, tc.tags, level)
+		}
+	}
+}
+
+func TestSetSuiteResults(t *testing.T) {
+	suite, err := LoadSummary(filepath.Join("testdata", SummaryFile))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	summary := NewSummary(loadFixture(t))
+	summary.SetSuiteResults(suite)
+
+	if summary.Controller != "example.com/ingress-controller" {
+		t.Errorf("expected the controller of the suite but got %q", summary.Controller)
+	}
+
+	if len(summary.Unsupported) != 1 || summary.Unsupported[0].Line != 82 {
+		t.Errorf("expected the unsupported scenarios of the suite but got %+v", summary.Unsupported)
+	}
+
+	if !strings.Contains(summary.String(), "1 scenarios unsupported by profile") {
+		t.Errorf("expected the unsupported scenarios in the description %q", summary)
+	}
+
+	missing, err := LoadSummary(filepath.Join(t.TempDir(), SummaryFile))
+	if err != nil || missing != nil {
+		t.Errorf("expected no summary and no error reading a missing file but got %v (%v)", missing, err)
+	}
+}
+
+func TestWriteJSON(t *testing.T) {
+	buf := &bytes.Buffer{}
+	if err := NewSummary(loadFixture(t)).WriteJSON(buf); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	summary := &Summary{}
+	if err := json.Unmarshal(buf.Bytes(), summary); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if summary.Status != StatusFailed || summary.Counts.Total != 4 || len(summary.Features) != 2 {
+		t.Errorf("unexpected summary %+v", summary)
+	}
+}
@@ -0,0 +1,160 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package report
+
+// layoutTemplate defines the common structure of the pages. Pages
+// define the template "content".
+const layoutTemplate = `<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>{{ .Title }}</title>
+<style>
+body { font-family: -apple-system, "Segoe UI", Helvetica, Arial, sans-serif; margin: 0; color: #24292e; }
+header { background: #326ce5; color: #fff; padding: 16px 32px; }
+header a { color: #fff; text-decoration: none; }
+main { padding: 16px 32px; }
+table { border-collapse: collapse; width: 100%; margin-bottom: 24px; }
+th, td { border-bottom: 1px solid #e1e4e8; padding: 6px 8px; text-align: left; vertical-align: top; }
+th { background: #f6f8fa; }
+pre { background: #f6f8fa; padding: 8px; white-space: pre-wrap; margin: 4px 0; }
+.passed { color: #22863a; }
+.failed { color: #cb2431; }
+.skipped, .undefined, .pending { color: #b08800; }
+.status { font-weight: bold; text-transform: uppercase; }
+.bar { display: flex; height: 12px; min-width: 120px; background: #e1e4e8; }
+.bar span { display: block; height: 100%; }
+.bar .passed { background: #28a745; }
+.bar .failed { background: #d73a49; }
+.bar .skipped { background: #ffd33d; }
+.scenario { border: 1px solid #e1e4e8; margin-bottom: 16px; }
+.scenario h3 { margin: 0; padding: 8px; background: #f6f8fa; }
+.tags { color: #6a737d; font-size: 0.9em; }
+.rows td { border: 1px solid #e1e4e8; padding: 2px 6px; }
+.rows { width: auto; margin: 4px 0 4px 24px; }
+</style>
+</head>
+<body>
+<header><a href="index.html"><strong>{{ .Title }}</strong></a> <span>generated {{ date .Created }}</span></header>
+<main>
+{{ template "content" . }}
+</main>
+</body>
+</html>
+{{ define "bar" }}<div class="bar"><span class="passed" style="width: {{ percent .Passed .Total }}%"></span><span class="failed" style="width: {{ percent .Failed .Total }}%"></span><span class="skipped" style="width: {{ percent .Skipped .Total }}%"></span></div>{{ end }}
+{{ define "counts" }}<td>{{ .Total }}</td><td class="passed">{{ .Passed }}</td><td class="failed">{{ .Failed }}</td><td class="skipped">{{ .Skipped }}</td><td>{{ template "bar" . }}</td>{{ end }}
+`
+
+// indexContent renders the overview of the features, tags and the trend
+const indexContent = `{{ define "content" }}
+<h1>Summary <span class="status {{ .Summary.Status }}">{{ .Summary.Status }}</span></h1>
+<p>{{ .Summary }} - {{ duration .Summary.Duration }}</p>
+{{- if .Summary.Controller }}
+<p>Controller: {{ .Summary.Controller }}{{ if .Summary.Level }} - conformance level {{ .Summary.Level }} passed in full{{ end }}</p>
+{{- end }}
+
+<h2>Features</h2>
+<table>
+<tr><th>Feature</th><th>Status</th><th>Duration</th><th>Scenarios</th><th>Passed</th><th>Failed</th><th>Skipped</th><th></th></tr>
+{{- range .Features }}
+<tr>
+<td><a href="{{ .File }}">{{ .Summary.Name }}</a><div class="tags">{{ .Summary.URI }}</div></td>
+<td class="status {{ .Summary.Status }}">{{ .Summary.Status }}</td>
+<td>{{ duration .Summary.Duration }}</td>
+{{ template "counts" .Summary.Counts }}
+</tr>
+{{- end }}
+</table>
+
+<h2>Tags</h2>
+<table>
+<tr><th>Tag</th><th>Scenarios</th><th>Passed</th><th>Failed</th><th>Skipped</th><th></th></tr>
+{{- $tags := .Summary.Tags }}
+{{- range .Tags }}
+<tr><td>{{ . }}</td>{{ template "counts" (index $tags .) }}</tr>
+{{- end }}
+</table>
+
+{{- if .Summary.Unsupported }}
+<h2>Unsupported by profile</h2>
+<table>
+<tr><th>Feature</th><th>Scenario</th><th>Tags</th></tr>
+{{- range .Summary.Unsupported }}
+<tr>
+<td>{{ .Feature }}<div class="tags">{{ .URI }}</div></td>
+<td>{{ .Name }}<div class="tags">line {{ .Line }}</div></td>
+<td class="tags">{{ range .Tags }}{{ . }} {{ end }}</td>
+</tr>
+{{- end }}
+</table>
+{{- end }}
+
+{{- if .Trend }}
+<h2>Trend</h2>
+<table>
+<tr><th>Date</th><th>Build</th><th>Status</th><th>Level</th><th>Duration</th><th>Scenarios</th><th>Passed</th><th>Failed</th><th>Skipped</th><th></th></tr>
+{{- range .Trend }}
+<tr>
+<td>{{ date .Date }}</td>
+<td>{{ .Build }}</td>
+<td class="status {{ .Status }}">{{ .Status }}</td>
+<td>{{ .Level }}</td>
+<td>{{ duration .Duration }}</td>
+{{ template "counts" .Counts }}
+</tr>
+{{- end }}
+</table>
+{{- end }}
+{{ end }}`
+
+// featureContent renders the scenarios of a feature, including steps and errors
+const featureContent = `{{ define "content" }}
+{{- with .Feature }}
+<h1>{{ .Summary.Name }} <span class="status {{ .Summary.Status }}">{{ .Summary.Status }}</span></h1>
+<p class="tags">{{ .Summary.URI }} {{ range .Summary.Tags }}{{ . }} {{ end }}</p>
+<p>{{ .Summary.Counts.Total }} scenarios ({{ .Summary.Counts.Passed }} passed, {{ .Summary.Counts.Failed }} failed, {{ .Summary.Counts.Skipped }} skipped) - {{ duration .Summary.Duration }}</p>
+
+{{- range .Scenarios }}
+<div class="scenario">
+<h3><span class="status {{ .Summary.Status }}">{{ .Summary.Status }}</span> {{ .Summary.Name }}</h3>
+<table>
+<tr><td colspan="3" class="tags">line {{ .Summary.Line }} - {{ duration .Summary.Duration }} - {{ range .Summary.Tags }}{{ . }} {{ end }}</td></tr>
+{{- range .Steps }}
+<tr>
+<td class="status {{ .Result.Status }}">{{ .Result.Status }}</td>
+<td>
+<strong>{{ .Keyword }}</strong>{{ .Name }}
+{{- if .DataTable }}
+<table class="rows">
+{{- range .DataTable }}
+<tr>{{ range .Cells }}<td>{{ . }}</td>{{ end }}</tr>
+{{- end }}
+</table>
+{{- end }}
+{{- if .Result.Error }}
+<pre class="failed">{{ .Result.Error }}</pre>
+{{- end }}
+</td>
+<td>{{ stepDuration .Result.Duration }}</td>
+</tr>
+{{- end }}
+</table>
+</div>
+{{- end }}
+{{- end }}
+{{ end }}`
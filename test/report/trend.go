@@ -0,0 +1,96 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package report
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"time"
+)
+
+// Run is the result of a conformance run, stored to show the trend of the results
+type Run struct {
+	Build    string    `json:"build"`
+	Date     time.Time `json:"date"`
+	Status   string    `json:"status"`
+	Duration float64   `json:"duration"`
+	Counts   Counts    `json:"counts"`
+
+	// Level is the conformance level passed in full
+	Level string `json:"level,omitempty"`
+	// Unsupported is the number of scenarios unsupported by the profile
+	Unsupported int `json:"unsupported,omitempty"`
+}
+
+// NewRun returns the run described by the summary
+func NewRun(build string, date time.Time, summary *Summary) Run {
+	return Run{
+		Build:    build,
+		Date:     date,
+		Status:   summary.Status,
+		Duration: summary.Duration,
+		Counts:   summary.Counts,
+
+		Level:       summary.Level,
+		Unsupported: len(summary.Unsupported),
+	}
+}
+
+// LoadTrend reads the runs stored in a JSON file. A missing file is an empty trend.
+func LoadTrend(path string) ([]Run, error) {
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return []Run{}, nil
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("error reading trend file %v: %v", path, err)
+	}
+
+	runs := []Run{}
+	if err := json.Unmarshal(data, &runs); err != nil {
+		return nil, fmt.Errorf("trend file %v is not valid: %v", path, err)
+	}
+
+	return runs, nil
+}
+
+// SaveTrend writes the runs in a JSON file
+func SaveTrend(path string, runs []Run) error {
+	data, err := json.MarshalIndent(runs, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if err := ioutil.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("error writing trend file %v: %v", path, err)
+	}
+
+	return nil
+}
+
+// AppendRun adds a run to the trend keeping only the last max runs
+func AppendRun(runs []Run, run Run, max int) []Run {
+	runs = append(runs, run)
+	if max > 0 && len(runs) > max {
+		runs = runs[len(runs)-max:]
+	}
+
+	return runs
+}
@@ -0,0 +1,96 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package report
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestAppendRun(t *testing.T) {
+	runs := func(builds ...string) []Run {
+		result := []Run{}
+		for _, build := range builds {
+			result = append(result, Run{Build: build})
+		}
+
+		return result
+	}
+
+	testCases := []struct {
+		trend    []Run
+		max      int
+		expected []Run
+	}{
+		{runs(), 3, runs("new")},
+		{runs("1", "2"), 3, runs("1", "2", "new")},
+		{runs("1", "2", "3"), 3, runs("2", "3", "new")},
+		{runs("1", "2", "3"), 0, runs("1", "2", "3", "new")},
+	}
+
+	for _, tc := range testCases {
+		trend := AppendRun(tc.trend, Run{Build: "new"}, tc.max)
+		if !reflect.DeepEqual(trend, tc.expected) {
+			t.Errorf("expected trend %v but got %v", tc.expected, trend)
+		}
+	}
+}
+
+func TestTrend(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "trend.json")
+
+	trend, err := LoadTrend(path)
+	if err != nil || len(trend) != 0 {
+		t.Fatalf("expected an empty trend reading a missing file but got %v (%v)", trend, err)
+	}
+
+	summary := NewSummary(loadFixture(t))
+	summary.Level = "core"
+	summary.Unsupported = []UnsupportedScenario{{Name: "A wildcard host"}}
+
+	date := time.Date(2020, 10, 1, 12, 0, 0, 0, time.UTC)
+	run := NewRun("build-1", date, summary)
+
+	expected := Run{
+		Build:       "build-1",
+		Date:        date,
+		Status:      StatusFailed,
+		Duration:    4.75,
+		Counts:      Counts{Total: 4, Passed: 2, Failed: 1, Skipped: 1},
+		Level:       "core",
+		Unsupported: 1,
+	}
+
+	if !reflect.DeepEqual(run, expected) {
+		t.Errorf("expected run %+v but got %+v", expected, run)
+	}
+
+	if err := SaveTrend(path, AppendRun(trend, run, 10)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	trend, err = LoadTrend(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !reflect.DeepEqual(trend, []Run{expected}) {
+		t.Errorf("expected the saved trend but got %+v", trend)
+	}
+}
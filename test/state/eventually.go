@@ -0,0 +1,166 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package state
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+var (
+	// DefaultRetryTimeout is the maximum duration of Eventually in a new scenario
+	DefaultRetryTimeout = time.Minute
+	// DefaultRetryInterval is the time to wait between the requests sent by Eventually
+	DefaultRetryInterval = time.Second
+)
+
+// maxAttemptsReported limits the number of attempts included in errors
+const maxAttemptsReported = 10
+
+// Condition checks the response of the last request of the scenario
+type Condition func(*Scenario) error
+
+// StatusCodeIs checks the status code of the response
+func StatusCodeIs(code int) Condition {
+	return func(f *Scenario) error {
+		if f.StatusCode != code {
+			return fmt.Errorf("expected status code %v but %v was returned", code, f.StatusCode)
+		}
+
+		return nil
+	}
+}
+
+// BodyContains checks the body of the response contains a text
+func BodyContains(text string) Condition {
+	return func(f *Scenario) error {
+		if !bytes.Contains(f.ResponseBody, []byte(text)) {
+			return fmt.Errorf("expected a response body containing %q", text)
+		}
+
+		return nil
+	}
+}
+
+// HeaderIs checks the value of a header of the response
+func HeaderIs(header, value string) Condition {
+	return func(f *Scenario) error {
+		values, ok := f.ResponseHeaders[http.CanonicalHeaderKey(header)]
+		if !ok {
+			return fmt.Errorf("expected the response header %v but it is not present", header)
+		}
+
+		if values[0] != value {
+			return fmt.Errorf("expected the response header %v with value %v but %v was returned", header, value, values[0])
+		}
+
+		return nil
+	}
+}
+
+// All checks all the conditions, returning the first error
+func All(conditions ...Condition) Condition {
+	return func(f *Scenario) error {
+		for _, condition := range conditions {
+			if err := condition(f); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+}
+
+// Attempt is a request sent by Eventually
+type Attempt struct {
+	// Elapsed is the time since the first request
+	Elapsed time.Duration
+	// StatusCode of the response (0 if the request failed)
+	StatusCode int
+	// Err is the error sending the request or checking the condition
+	Err error
+}
+
+func (a Attempt) String() string {
+	return fmt.Sprintf("%v: status %v: %v", a.Elapsed.Round(time.Millisecond), a.StatusCode, a.Err)
+}
+
+// Eventually sends the requests returned by newRequest until the condition holds
+// or RetryTimeout expires, waiting RetryInterval between requests. Controllers
+// reconcile asynchronously, so an Ingress with an address does not imply the
+// rules are programmed. Each request uses the remaining time as deadline, so a
+// request without response is a failed attempt. On timeout, the error contains
+// the history of attempts.
+func (f *Scenario) Eventually(newRequest func() (*http.Request, error), condition Condition) error {
+	var attempts []Attempt
+
+	start := time.Now()
+	for {
+		req, err := newRequest()
+		if err != nil {
+			return err
+		}
+
+		ctx, cancel := context.WithTimeout(req.Context(), f.RetryTimeout-time.Since(start))
+		err = f.SendRequest(req.WithContext(ctx))
+		cancel()
+
+		if err == nil {
+			err = condition(f)
+		}
+
+		if err == nil {
+			return nil
+		}
+
+		attempts = append(attempts, Attempt{Elapsed: time.Since(start), StatusCode: f.StatusCode, Err: err})
+
+		if time.Since(start)+f.RetryInterval > f.RetryTimeout {
+			return &RetryError{Timeout: f.RetryTimeout, Attempts: attempts}
+		}
+
+		time.Sleep(f.RetryInterval)
+	}
+}
+
+// RetryError is returned by Eventually when the condition does not hold before the timeout
+type RetryError struct {
+	Timeout  time.Duration
+	Attempts []Attempt
+}
+
+func (e *RetryError) Error() string {
+	buf := &strings.Builder{}
+	fmt.Fprintf(buf, "condition not satisfied after %v attempts in %v", len(e.Attempts), e.Timeout)
+
+	attempts := e.Attempts
+	if omitted := len(attempts) - maxAttemptsReported; omitted > 0 {
+		fmt.Fprintf(buf, " (%v attempts omitted)", omitted)
+		attempts = attempts[omitted:]
+	}
+
+	fmt.Fprintf(buf, ":")
+	for _, attempt := range attempts {
+		fmt.Fprintf(buf, "\n  %v", attempt)
+	}
+
+	return buf.String()
+}
@@ -0,0 +1,126 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package state
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// newServer returns a server replying 503 to the first requests
+func newServer(unavailable int32) *httptest.Server {
+	var requests int32
+
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&requests, 1) <= unavailable {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+
+		w.Header().Set("X-Ready", "true")
+		_, _ = w.Write([]byte("ready"))
+	}))
+}
+
+func newScenario(timeout time.Duration) *Scenario {
+	scenario := New(nil)
+	scenario.RetryTimeout = timeout
+	scenario.RetryInterval = 10 * time.Millisecond
+
+	return scenario
+}
+
+func TestEventually(t *testing.T) {
+	server := newServer(3)
+	defer server.Close()
+
+	scenario := newScenario(time.Second)
+
+	err := scenario.Eventually(func() (*http.Request, error) {
+		return http.NewRequest(http.MethodGet, server.URL, nil)
+	}, All(StatusCodeIs(http.StatusOK), BodyContains("ready"), HeaderIs("X-Ready", "true")))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if scenario.StatusCode != http.StatusOK {
+		t.Errorf("expected the state of the last response but status code is %v", scenario.StatusCode)
+	}
+}
+
+func TestEventuallyTimeout(t *testing.T) {
+	server := newServer(1000)
+	defer server.Close()
+
+	scenario := newScenario(100 * time.Millisecond)
+
+	err := scenario.Eventually(func() (*http.Request, error) {
+		return http.NewRequest(http.MethodGet, server.URL, nil)
+	}, StatusCodeIs(http.StatusOK))
+
+	var retryErr *RetryError
+	if !errors.As(err, &retryErr) {
+		t.Fatalf("expected a retry error but %v was returned", err)
+	}
+
+	if len(retryErr.Attempts) < 2 {
+		t.Errorf("expected multiple attempts but %v were sent", len(retryErr.Attempts))
+	}
+
+	if !strings.Contains(err.Error(), "expected status code 200 but 503 was returned") {
+		t.Errorf("expected the history of attempts in the error: %v", err)
+	}
+}
+
+func TestEventuallyRequestWithoutResponse(t *testing.T) {
+	done := make(chan struct{})
+
+	// the server accepts the request but never replies
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-done:
+		}
+	}))
+	defer server.Close()
+	defer close(done)
+
+	scenario := newScenario(200 * time.Millisecond)
+
+	start := time.Now()
+	err := scenario.Eventually(func() (*http.Request, error) {
+		return http.NewRequest(http.MethodGet, server.URL, nil)
+	}, StatusCodeIs(http.StatusOK))
+
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("expected the request to stop after the retry timeout but it took %v", elapsed)
+	}
+
+	var retryErr *RetryError
+	if !errors.As(err, &retryErr) {
+		t.Fatalf("expected a retry error but %v was returned", err)
+	}
+
+	if len(retryErr.Attempts) != 1 || retryErr.Attempts[0].StatusCode != 0 {
+		t.Errorf("expected the request without response as a failed attempt but got %v", retryErr.Attempts)
+	}
+}
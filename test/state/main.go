@@ -1,15 +1,23 @@
 package state
 
 import (
+	"crypto/tls"
+	"fmt"
 	"io/ioutil"
+	"net"
 	"net/http"
+	"time"
 
-	v1beta1 "k8s.io/api/networking/v1beta1"
+	networkingv1 "k8s.io/api/networking/v1"
+
+	"github.com/aledbf/ingress-conformance-bdd/test/echo"
 )
 
 // Scenario holds state for a test scenario
 type Scenario struct {
 	client *http.Client
+	// clients used in HTTPS requests by server name (SNI)
+	tlsClients map[string]*http.Client
 
 	RequestPath string
 
@@ -24,8 +32,27 @@ type Scenario struct {
 
 	IngressManifest string
 
-	Ingress *v1beta1.Ingress
+	// ManifestValues contains the values of the scenario used to
+	// render the manifests, in addition to the default values.
+	ManifestValues map[string]string
+
+	// Ingress holds the networking.k8s.io/v1 representation of the
+	// Ingress, independently of the version served by the cluster.
+	Ingress *networkingv1.Ingress
 	Address string
+
+	// ServerName is the value used for SNI in HTTPS requests.
+	// If empty, the value of the Host request header is used.
+	ServerName string
+
+	// TLS contains information about the TLS connection
+	// of the last HTTPS request (nil for HTTP requests)
+	TLS *tls.ConnectionState
+
+	// RetryTimeout is the maximum duration of Eventually
+	RetryTimeout time.Duration
+	// RetryInterval is the time to wait between the requests sent by Eventually
+	RetryInterval time.Duration
 }
 
 // New creates a new state to use in a test Scenario
@@ -36,40 +63,145 @@ func New(client *http.Client) *Scenario {
 
 	return &Scenario{
 		client:         client,
+		tlsClients:     make(map[string]*http.Client),
 		RequestPath:    "/",
 		RequestHeaders: make(http.Header),
+		ManifestValues: make(map[string]string),
+		RetryTimeout:   DefaultRetryTimeout,
+		RetryInterval:  DefaultRetryInterval,
+	}
+}
+
+// Reset removes the information of a previous scenario keeping
+// the HTTP client. This allows sharing the same instance between
+// the steps of a feature and the common step library.
+func (f *Scenario) Reset() {
+	f.CloseIdleConnections()
+	*f = *New(f.client)
+}
+
+// CloseIdleConnections closes the idle connections of the
+// HTTP client and the clients used in HTTPS requests
+func (f *Scenario) CloseIdleConnections() {
+	f.client.CloseIdleConnections()
+
+	for _, client := range f.tlsClients {
+		client.CloseIdleConnections()
 	}
 }
 
 // SendRequest sends an HTTP request and updates the
 // state. In case of an error, the HTTP state is
 // removed and returns an error.
+// The request uses a copy of the request headers of the scenario,
+// replaced by the headers already set in the request.
+// HTTPS requests do not verify the server certificate,
+// allowing steps to inspect the TLS connection state.
 func (f *Scenario) SendRequest(req *http.Request) error {
-	req.Header = f.RequestHeaders
+	headers := f.RequestHeaders.Clone()
+	if headers == nil {
+		headers = make(http.Header)
+	}
 
-	resp, err := f.client.Do(req)
-	if err != nil {
-		f.ResponseBody = nil
-		f.StatusCode = 0
-		f.ResponseHeaders = nil
+	for name, values := range req.Header {
+		headers[name] = values
+	}
+
+	req.Header = headers
+
+	// the Host header is ignored by the http client
+	if host := headers.Get("Host"); host != "" {
+		req.Host = host
+	}
 
+	client := f.client
+	if req.URL.Scheme == "https" {
+		client = f.tlsClient(req)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		f.resetResponse()
 		return err
 	}
 
+	defer resp.Body.Close()
+
 	bodyBytes, err := ioutil.ReadAll(resp.Body)
 	if err != nil {
+		f.resetResponse()
 		return err
 	}
 
 	f.ResponseBody = bodyBytes
 	f.ResponseHeaders = resp.Header.Clone()
 	f.StatusCode = resp.StatusCode
-
-	defer resp.Body.Close()
+	f.TLS = resp.TLS
 
 	return nil
 }
 
+// resetResponse removes the HTTP state of the last request
+func (f *Scenario) resetResponse() {
+	f.ResponseBody = nil
+	f.StatusCode = 0
+	f.ResponseHeaders = nil
+	f.TLS = nil
+}
+
+// tlsClient returns an HTTP client using the server name
+// of the scenario (or the Host header) for SNI. Clients are
+// reused, avoiding a new transport in every request.
+func (f *Scenario) tlsClient(req *http.Request) *http.Client {
+	serverName := f.ServerName
+	if serverName == "" {
+		serverName = req.Host
+	}
+
+	if host, _, err := net.SplitHostPort(serverName); err == nil {
+		serverName = host
+	}
+
+	if client, ok := f.tlsClients[serverName]; ok {
+		return client
+	}
+
+	if f.tlsClients == nil {
+		f.tlsClients = make(map[string]*http.Client)
+	}
+
+	client := &http.Client{
+		Timeout:       f.client.Timeout,
+		CheckRedirect: f.client.CheckRedirect,
+		Transport: &http.Transport{
+			Proxy: http.ProxyFromEnvironment,
+			// every request negotiates TLS, returning the
+			// certificate currently served by the controller
+			DisableKeepAlives: true,
+			TLSClientConfig: &tls.Config{
+				ServerName: serverName,
+				// the certificate is verified in the steps
+				InsecureSkipVerify: true, // nolint:gosec
+			},
+		},
+	}
+
+	f.tlsClients[serverName] = client
+
+	return client
+}
+
+// EchoResponse returns the description of the request received by the
+// backend, parsing the body of the last response. This requires the
+// backend is the conformance echo server (images/echoserver).
+func (f *Scenario) EchoResponse() (*echo.Response, error) {
+	if f.ResponseBody == nil {
+		return nil, fmt.Errorf("the last request did not return a response")
+	}
+
+	return echo.Parse(f.ResponseBody)
+}
+
 // AddRequestHeader Add adds the key, value pair to the header.
 // It appends to any existing values associated with key.
 func (f *Scenario) AddRequestHeader(header, value string) {
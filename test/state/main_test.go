@@ -0,0 +1,126 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package state
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestSendRequestHeaders(t *testing.T) {
+	hosts := make(chan string, 2)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hosts <- r.Host
+		w.Header().Set("X-Test", r.Header.Get("X-Test"))
+	}))
+	defer server.Close()
+
+	scenario := New(nil)
+	scenario.AddRequestHeader("X-Test", "scenario")
+
+	// the header of the request replaces the header of the scenario
+	req, _ := http.NewRequest(http.MethodGet, server.URL, nil)
+	req.Header.Set("Host", "foo.bar")
+	req.Header.Set("X-Extra", "request")
+
+	if err := scenario.SendRequest(req); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if host := <-hosts; host != "foo.bar" {
+		t.Errorf("expected host foo.bar but got %v", host)
+	}
+
+	if scenario.ResponseHeaders.Get("X-Test") != "scenario" {
+		t.Errorf("expected the headers of the scenario in the request")
+	}
+
+	// headers of a request are not added to the scenario
+	if scenario.RequestHeaders.Get("Host") != "" || scenario.RequestHeaders.Get("X-Extra") != "" {
+		t.Errorf("unexpected headers of the scenario %v", scenario.RequestHeaders)
+	}
+
+	req, _ = http.NewRequest(http.MethodGet, server.URL, nil)
+	if err := scenario.SendRequest(req); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if host := <-hosts; host == "foo.bar" {
+		t.Errorf("expected the host of the server but the previous request host was used")
+	}
+}
+
+func TestTLSClientReused(t *testing.T) {
+	scenario := New(nil)
+
+	newRequest := func(host string) *http.Request {
+		req, _ := http.NewRequest(http.MethodGet, "https://127.0.0.1", nil)
+		req.Host = host
+
+		return req
+	}
+
+	client := scenario.tlsClient(newRequest("foo.bar"))
+	if scenario.tlsClient(newRequest("foo.bar:443")) != client {
+		t.Errorf("expected the same client for the server name foo.bar")
+	}
+
+	if scenario.tlsClient(newRequest("bar.foo")) == client {
+		t.Errorf("expected a different client for the server name bar.foo")
+	}
+
+	scenario.Reset()
+
+	if len(scenario.tlsClients) != 0 {
+		t.Errorf("expected the clients removed after a reset")
+	}
+}
+
+func TestSendRequestBodyError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/truncated" {
+			// the connection is closed before the body is complete
+			w.Header().Set("Content-Length", "100")
+			_, _ = w.Write([]byte("truncated"))
+			return
+		}
+
+		w.Header().Set("X-Test", "complete")
+		_, _ = w.Write([]byte("complete"))
+	}))
+	defer server.Close()
+
+	scenario := New(nil)
+
+	req, _ := http.NewRequest(http.MethodGet, server.URL, nil)
+	if err := scenario.SendRequest(req); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	req, _ = http.NewRequest(http.MethodGet, server.URL+"/truncated", nil)
+	if err := scenario.SendRequest(req); err == nil {
+		t.Fatalf("expected an error reading the body of the response")
+	}
+
+	// the state of the previous response is removed
+	if scenario.StatusCode != 0 || scenario.ResponseBody != nil || scenario.ResponseHeaders != nil {
+		t.Errorf("expected the HTTP state removed but got status %v, body %q and headers %v",
+			scenario.StatusCode, scenario.ResponseBody, scenario.ResponseHeaders)
+	}
+}
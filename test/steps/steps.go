@@ -0,0 +1,540 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package steps contains the step definitions shared by all the features.
+// Steps registered here are ignored by hack/codegen.go when it generates
+// the go code of a feature.
+package steps
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cucumber/godog"
+	"github.com/cucumber/messages-go/v10"
+
+	tstate "github.com/aledbf/ingress-conformance-bdd/test/state"
+	"github.com/aledbf/ingress-conformance-bdd/test/utils"
+)
+
+// feature holds the state of the running scenario. The
+// same instance is used by the steps defined in the feature.
+type feature struct {
+	// holds state of the scenarario
+	state *tstate.Scenario
+}
+
+func (f *feature) aNewRandomNamespace() error {
+	var err error
+
+	f.state.Namespace, err = utils.CreateTestNamespace(utils.KubeClient)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (f *feature) readingIngressFromManifest(file string) error {
+	var err error
+
+	f.state.Ingress, err = utils.IngressFromManifest(file, f.state.Namespace, f.manifestValues())
+	if err != nil {
+		return err
+	}
+
+	f.state.IngressManifest = file
+
+	return nil
+}
+
+func (f *feature) creatingIngressFromManifest() error {
+	_, err := utils.CreateIngress(utils.KubeClient, f.state.Ingress)
+	return err
+}
+
+func (f *feature) creatingIngressFromManifestReturnsAnErrorMessageContaining(message string) error {
+	_, err := utils.CreateIngress(utils.KubeClient, f.state.Ingress)
+	if err == nil {
+		return fmt.Errorf("expected an error creating the Ingress from manifest %v", f.state.IngressManifest)
+	}
+
+	if strings.Contains(err.Error(), message) {
+		return nil
+	}
+
+	if utils.IsIngressV1() && strings.Contains(err.Error(), utils.IngressV1FieldPath(message)) {
+		return nil
+	}
+
+	return fmt.Errorf("expected an error containing %v but returned %v", message, err.Error())
+}
+
+func (f *feature) creatingObjectsFromDirectory(path string) error {
+	ing, err := utils.CreateFromPath(utils.ObjectsClient, path, f.state.Namespace, f.manifestValues())
+	if err != nil {
+		return err
+	}
+
+	// directories may contain only backends
+	if ing != nil {
+		f.state.Ingress = ing
+	}
+
+	return nil
+}
+
+func (f *feature) applyingManifest(path string) error {
+	ing, err := utils.ObjectsClient.ApplyFromPath(path, f.state.Namespace, f.manifestValues())
+	if err != nil {
+		return err
+	}
+
+	if ing != nil {
+		f.state.Ingress = ing
+	}
+
+	return nil
+}
+
+func (f *feature) theManifestValues(table *messages.PickleStepArgument_PickleTable) error {
+	names, err := tableColumn(table, "name")
+	if err != nil {
+		return err
+	}
+
+	values, err := tableColumn(table, "value")
+	if err != nil {
+		return err
+	}
+
+	for i, name := range names {
+		f.state.ManifestValues[name] = values[i]
+	}
+
+	return nil
+}
+
+// manifestValues returns the values used to render the manifests of the scenario.
+// The random suffix is generated once, so all the manifests use the same value.
+func (f *feature) manifestValues() utils.TemplateValues {
+	values := utils.NewTemplateValues(f.state.Namespace)
+	if _, ok := f.state.ManifestValues["Suffix"]; !ok {
+		f.state.ManifestValues["Suffix"] = values["Suffix"]
+	}
+
+	for name, value := range f.state.ManifestValues {
+		values[name] = value
+	}
+
+	return values
+}
+
+func (f *feature) theIngressStatusShowsTheIPAddressOrFQDNWhereIsExposed() error {
+	if f.state.Ingress == nil {
+		return fmt.Errorf("feature without Ingress associated")
+	}
+
+	address, err := utils.WaitForIngressAddress(utils.KubeClient, f.state.Namespace,
+		f.state.Ingress.GetName(), utils.WaitForIngressAddressTimeout)
+	if err != nil {
+		return err
+	}
+
+	f.state.Address = address
+
+	return nil
+}
+
+func (f *feature) theRequestHeaderWithValue(header, value string) error {
+	f.state.AddRequestHeader(header, value)
+	return nil
+}
+
+func (f *feature) theTLSServerNameIs(name string) error {
+	f.state.ServerName = name
+	return nil
+}
+
+func (f *feature) theRequestPath(path string) error {
+	f.state.RequestPath = path
+	return nil
+}
+
+func (f *feature) sendHTTPRequestWithMethod(method string) error {
+	req, err := http.NewRequest(method, utils.IngressURL("http", f.state.Address, f.state.RequestPath), nil)
+	if err != nil {
+		return err
+	}
+
+	return f.state.SendRequest(req)
+}
+
+func (f *feature) sendHTTPSRequestWithMethod(method string) error {
+	req, err := http.NewRequest(method, utils.IngressURL("https", f.state.Address, f.state.RequestPath), nil)
+	if err != nil {
+		return err
+	}
+
+	return f.state.SendRequest(req)
+}
+
+func (f *feature) theRequestsAreRetriedForUpTo(timeout string) error {
+	duration, err := time.ParseDuration(timeout)
+	if err != nil {
+		return fmt.Errorf("invalid retry timeout %v: %v", timeout, err)
+	}
+
+	f.state.RetryTimeout = duration
+
+	return nil
+}
+
+func (f *feature) eventuallySendHTTPRequestWithMethodUntilTheResponseStatusCodeIs(method string, code int) error {
+	return f.eventually("http", method, tstate.StatusCodeIs(code))
+}
+
+func (f *feature) eventuallySendHTTPSRequestWithMethodUntilTheResponseStatusCodeIs(method string, code int) error {
+	return f.eventually("https", method, tstate.StatusCodeIs(code))
+}
+
+func (f *feature) eventuallySendHTTPRequestWithMethodUntilTheResponseBodyContains(method, text string) error {
+	return f.eventually("http", method, tstate.BodyContains(text))
+}
+
+func (f *feature) eventuallySendHTTPRequestWithMethodUntilTheResponseHeaderHasValue(method, header, value string) error {
+	return f.eventually("http", method, tstate.HeaderIs(header, value))
+}
+
+// eventually sends requests to the address of the Ingress, using the path
+// and headers of the scenario, until the condition holds or the retry
+// timeout of the scenario expires.
+func (f *feature) eventually(scheme, method string, condition tstate.Condition) error {
+	return f.state.Eventually(func() (*http.Request, error) {
+		return http.NewRequest(method, utils.IngressURL(scheme, f.state.Address, f.state.RequestPath), nil)
+	}, condition)
+}
+
+func (f *feature) sendHTTPRequestWithPathAndMethodCheckingResponseStatusCodeIs(code int, table *messages.PickleStepArgument_PickleTable) error {
+	if len(table.Rows) < 2 {
+		return fmt.Errorf("expected a table with at least one row")
+	}
+
+	for i, row := range table.Rows {
+		if i == 0 {
+			continue
+		}
+
+		path := row.Cells[0].Value
+		method := row.Cells[1].Value
+
+		req, err := http.NewRequest(method, utils.IngressURL("http", f.state.Address, path), nil)
+		if err != nil {
+			return err
+		}
+
+		err = f.state.SendRequest(req)
+		if err != nil {
+			return err
+		}
+
+		if code != f.state.StatusCode {
+			return fmt.Errorf("expected status code %v for path %v and method %v but %v was returned",
+				code, path, method, f.state.StatusCode)
+		}
+	}
+
+	return nil
+}
+
+func (f *feature) creatingEchoBackends(table *messages.PickleStepArgument_PickleTable) error {
+	names, err := tableColumn(table, "name")
+	if err != nil {
+		return err
+	}
+
+	for _, name := range names {
+		err := utils.CreateEchoBackend(utils.KubeClient, f.state.Namespace, name)
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, name := range names {
+		err := utils.WaitForEchoBackend(utils.KubeClient, f.state.Namespace, name)
+		if err != nil {
+			return fmt.Errorf("waiting for echo backend %v: %v", name, err)
+		}
+	}
+
+	return nil
+}
+
+func (f *feature) sendHTTPRequestWithPathAndMethodCheckingTheStatusAndBackend(table *messages.PickleStepArgument_PickleTable) error {
+	return f.sendRequestsFromTable(table)
+}
+
+func (f *feature) sendHTTPRequestWithHostPathAndMethodCheckingTheStatusAndBackend(table *messages.PickleStepArgument_PickleTable) error {
+	return f.sendRequestsFromTable(table)
+}
+
+// sendRequestsFromTable sends one HTTP request for each row of the table checking
+// the status code and the echo backend that served the request. The columns
+// are located using the name in the first row:
+//
+//	host:    optional, value of the Host header
+//	path:    required, path of the request
+//	method:  optional, HTTP method (GET by default)
+//	status:  required, expected status code
+//	backend: optional, name of the echo backend that served the request.
+//	         Empty or - when the request is not served by an echo backend.
+func (f *feature) sendRequestsFromTable(table *messages.PickleStepArgument_PickleTable) error {
+	if len(table.Rows) < 2 {
+		return fmt.Errorf("expected a table with at least one row")
+	}
+
+	columns := map[string]int{}
+	for i, cell := range table.Rows[0].Cells {
+		columns[strings.TrimSpace(cell.Value)] = i
+	}
+
+	for _, required := range []string{"path", "status"} {
+		if _, ok := columns[required]; !ok {
+			return fmt.Errorf("expected a table with a column %v", required)
+		}
+	}
+
+	value := func(row *messages.PickleStepArgument_PickleTable_PickleTableRow, column, defValue string) string {
+		index, ok := columns[column]
+		if !ok || strings.TrimSpace(row.Cells[index].Value) == "" {
+			return defValue
+		}
+
+		return strings.TrimSpace(row.Cells[index].Value)
+	}
+
+	for _, row := range table.Rows[1:] {
+		path := value(row, "path", "/")
+		method := value(row, "method", http.MethodGet)
+		backend := value(row, "backend", "-")
+
+		code, err := strconv.Atoi(value(row, "status", ""))
+		if err != nil {
+			return fmt.Errorf("invalid status code for path %v: %v", path, err)
+		}
+
+		req, err := http.NewRequest(method, utils.IngressURL("http", f.state.Address, path), nil)
+		if err != nil {
+			return err
+		}
+
+		// the host of the row applies only to its request
+		if host := value(row, "host", ""); host != "" {
+			req.Header.Set("Host", host)
+		}
+
+		err = f.state.SendRequest(req)
+		if err != nil {
+			return err
+		}
+
+		request := fmt.Sprintf("host %v, path %v and method %v", req.Host, path, method)
+		if code != f.state.StatusCode {
+			return fmt.Errorf("expected status code %v for %v but %v was returned",
+				code, request, f.state.StatusCode)
+		}
+
+		if backend == "-" {
+			continue
+		}
+
+		resp, err := f.state.EchoResponse()
+		if err != nil {
+			return fmt.Errorf("unexpected response for %v: %v", request, err)
+		}
+
+		if resp.Backend.Service != backend {
+			return fmt.Errorf("expected %v served by backend %v but %v was returned",
+				request, backend, resp.Backend.Service)
+		}
+	}
+
+	return nil
+}
+
+// tableColumn returns the values of a column, located by
+// name in the first row, excluding the first row.
+func tableColumn(table *messages.PickleStepArgument_PickleTable, name string) ([]string, error) {
+	if len(table.Rows) < 2 {
+		return nil, fmt.Errorf("expected a table with at least one row")
+	}
+
+	index := -1
+	for i, cell := range table.Rows[0].Cells {
+		if strings.TrimSpace(cell.Value) == name {
+			index = i
+		}
+	}
+
+	if index == -1 {
+		return nil, fmt.Errorf("expected a table with a column %v", name)
+	}
+
+	var values []string
+	for _, row := range table.Rows[1:] {
+		values = append(values, strings.TrimSpace(row.Cells[index].Value))
+	}
+
+	return values, nil
+}
+
+func (f *feature) theResponseStatusCodeIs(code int) error {
+	if f.state.StatusCode != code {
+		return fmt.Errorf("expected status code %v but %v was returned",
+			code, f.state.StatusCode)
+	}
+
+	return nil
+}
+
+func (f *feature) theResponseHeaderIsNotPresent(header string) error {
+	if value, ok := f.state.ResponseHeaders[header]; ok {
+		return fmt.Errorf("expected no header with name %v but exists (value %v)", header, value)
+	}
+
+	return nil
+}
+
+func (f *feature) theBackendReceivedPath(path string) error {
+	resp, err := f.state.EchoResponse()
+	if err != nil {
+		return err
+	}
+
+	if resp.Path != path {
+		return fmt.Errorf("expected the backend received the path %v but %v was received", path, resp.Path)
+	}
+
+	return nil
+}
+
+func (f *feature) theBackendReceivedMethod(method string) error {
+	resp, err := f.state.EchoResponse()
+	if err != nil {
+		return err
+	}
+
+	if resp.Method != method {
+		return fmt.Errorf("expected the backend received the method %v but %v was received", method, resp.Method)
+	}
+
+	return nil
+}
+
+func (f *feature) theBackendReceivedHost(host string) error {
+	resp, err := f.state.EchoResponse()
+	if err != nil {
+		return err
+	}
+
+	if resp.Host != host {
+		return fmt.Errorf("expected the backend received the host %v but %v was received", host, resp.Host)
+	}
+
+	return nil
+}
+
+func (f *feature) theBackendReceivedHeader(header string) error {
+	resp, err := f.state.EchoResponse()
+	if err != nil {
+		return err
+	}
+
+	if _, ok := resp.Header(header); !ok {
+		return fmt.Errorf("expected the backend received the header %v (headers: %v)", header, resp.Headers)
+	}
+
+	return nil
+}
+
+func (f *feature) theBackendReceivedHeaderWithValue(header, value string) error {
+	resp, err := f.state.EchoResponse()
+	if err != nil {
+		return err
+	}
+
+	received, ok := resp.Header(header)
+	if !ok {
+		return fmt.Errorf("expected the backend received the header %v (headers: %v)", header, resp.Headers)
+	}
+
+	if received != value {
+		return fmt.Errorf("expected the backend received the header %v with value %v but %v was received",
+			header, value, received)
+	}
+
+	return nil
+}
+
+// FeatureContext registers the common steps and the hooks to
+// reset the state and delete the namespace of each scenario.
+func FeatureContext(s *godog.Suite, state *tstate.Scenario) {
+	f := &feature{state: state}
+
+	s.Step(`^a new random namespace$`, f.aNewRandomNamespace)
+	s.Step(`^reading Ingress from manifest "([^"]*)"$`, f.readingIngressFromManifest)
+	s.Step(`^creating Ingress from manifest$`, f.creatingIngressFromManifest)
+	s.Step(`^creating Ingress from manifest returns an error message containing "([^"]*)"$`, f.creatingIngressFromManifestReturnsAnErrorMessageContaining)
+	s.Step(`^creating objects from directory "([^"]*)"$`, f.creatingObjectsFromDirectory)
+	s.Step(`^applying manifest "([^"]*)"$`, f.applyingManifest)
+	s.Step(`^the manifest values:$`, f.theManifestValues)
+	s.Step(`^the ingress status shows the IP address or FQDN where is exposed$`, f.theIngressStatusShowsTheIPAddressOrFQDNWhereIsExposed)
+	s.Step(`^the request header "([^"]*)" with value "([^"]*)"$`, f.theRequestHeaderWithValue)
+	s.Step(`^the TLS server name is "([^"]*)"$`, f.theTLSServerNameIs)
+	s.Step(`^the request path "([^"]*)"$`, f.theRequestPath)
+	s.Step(`^send HTTP request with method "([^"]*)"$`, f.sendHTTPRequestWithMethod)
+	s.Step(`^send HTTPS request with method "([^"]*)"$`, f.sendHTTPSRequestWithMethod)
+	s.Step(`^the requests are retried for up to "([^"]*)"$`, f.theRequestsAreRetriedForUpTo)
+	s.Step(`^eventually send HTTP request with method "([^"]*)" until the response status code is (\d+)$`, f.eventuallySendHTTPRequestWithMethodUntilTheResponseStatusCodeIs)
+	s.Step(`^eventually send HTTPS request with method "([^"]*)" until the response status code is (\d+)$`, f.eventuallySendHTTPSRequestWithMethodUntilTheResponseStatusCodeIs)
+	s.Step(`^eventually send HTTP request with method "([^"]*)" until the response body contains "([^"]*)"$`, f.eventuallySendHTTPRequestWithMethodUntilTheResponseBodyContains)
+	s.Step(`^eventually send HTTP request with method "([^"]*)" until the response header "([^"]*)" has value "([^"]*)"$`, f.eventuallySendHTTPRequestWithMethodUntilTheResponseHeaderHasValue)
+	s.Step(`^send HTTP request with <path> and <method> checking response status code is (\d+):$`, f.sendHTTPRequestWithPathAndMethodCheckingResponseStatusCodeIs)
+	s.Step(`^creating echo backends:$`, f.creatingEchoBackends)
+	s.Step(`^send HTTP request with <path> and <method> checking the <status> and <backend>:$`, f.sendHTTPRequestWithPathAndMethodCheckingTheStatusAndBackend)
+	s.Step(`^send HTTP request with <host>, <path> and <method> checking the <status> and <backend>:$`, f.sendHTTPRequestWithHostPathAndMethodCheckingTheStatusAndBackend)
+	s.Step(`^the response status code is (\d+)$`, f.theResponseStatusCodeIs)
+	s.Step(`^the response header "([^"]*)" is not present$`, f.theResponseHeaderIsNotPresent)
+	s.Step(`^the backend received path "([^"]*)"$`, f.theBackendReceivedPath)
+	s.Step(`^the backend received method "([^"]*)"$`, f.theBackendReceivedMethod)
+	s.Step(`^the backend received host "([^"]*)"$`, f.theBackendReceivedHost)
+	s.Step(`^the backend received header "([^"]*)"$`, f.theBackendReceivedHeader)
+	s.Step(`^the backend received header "([^"]*)" with value "([^"]*)"$`, f.theBackendReceivedHeaderWithValue)
+
+	s.BeforeScenario(func(this *messages.Pickle) {
+		f.state.Reset()
+	})
+
+	s.AfterScenario(func(*messages.Pickle, error) {
+		// delete namespace an all the content
+		_ = utils.DeleteKubeNamespace(utils.KubeClient, f.state.Namespace)
+		// IngressClasses are not removed with the namespace
+		_ = utils.CleanupIngressClasses(utils.KubeClient, f.state.Namespace)
+	})
+}
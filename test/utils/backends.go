@@ -0,0 +1,134 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package utils
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/client-go/kubernetes"
+)
+
+var (
+	// EchoServerImage image of the conformance echo server (images/echoserver)
+	EchoServerImage = "aledbf/ingress-conformance-echo:v0.0.1"
+)
+
+// CreateEchoBackend creates a Deployment running the echo server and a
+// Service with the same name, exposing the echo server in port 80.
+// The echo server reports the name of the service in the responses.
+func CreateEchoBackend(c kubernetes.Interface, namespace, name string) error {
+	labels := map[string]string{
+		"app": name,
+	}
+
+	replicas := int32(1)
+
+	deployment := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   name,
+			Labels: labels,
+		},
+		Spec: appsv1.DeploymentSpec{
+			Replicas: &replicas,
+			Selector: &metav1.LabelSelector{
+				MatchLabels: labels,
+			},
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: labels,
+				},
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{
+						{
+							Name:  "echo",
+							Image: EchoServerImage,
+							Env: []corev1.EnvVar{
+								{Name: "SERVICE_NAME", Value: name},
+								{Name: "POD_NAME", ValueFrom: fieldRef("metadata.name")},
+								{Name: "NAMESPACE", ValueFrom: fieldRef("metadata.namespace")},
+							},
+							Ports: []corev1.ContainerPort{
+								{ContainerPort: 8080},
+							},
+							ReadinessProbe: &corev1.Probe{
+								Handler: corev1.Handler{
+									HTTPGet: &corev1.HTTPGetAction{
+										Path: "/healthz",
+										Port: intstr.FromInt(8080),
+									},
+								},
+								PeriodSeconds:    1,
+								TimeoutSeconds:   1,
+								SuccessThreshold: 1,
+								FailureThreshold: 10,
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	_, err := c.AppsV1().Deployments(namespace).Create(context.TODO(), deployment, metav1.CreateOptions{})
+	if err != nil {
+		return fmt.Errorf("unable to create deployment %v: %v", name, err)
+	}
+
+	service := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   name,
+			Labels: labels,
+		},
+		Spec: corev1.ServiceSpec{
+			Selector: labels,
+			Ports: []corev1.ServicePort{
+				{
+					Name:       "http",
+					Port:       80,
+					TargetPort: intstr.FromInt(8080),
+					Protocol:   corev1.ProtocolTCP,
+				},
+			},
+		},
+	}
+
+	_, err = c.CoreV1().Services(namespace).Create(context.TODO(), service, metav1.CreateOptions{})
+	if err != nil {
+		return fmt.Errorf("unable to create service %v: %v", name, err)
+	}
+
+	return nil
+}
+
+// WaitForEchoBackend waits until the service of the echo backend has one endpoint.
+func WaitForEchoBackend(c kubernetes.Interface, namespace, name string) error {
+	return WaitForServiceEndpointsNum(c, namespace, name, 1, 2*time.Second, 5*time.Minute)
+}
+
+func fieldRef(path string) *corev1.EnvVarSource {
+	return &corev1.EnvVarSource{
+		FieldRef: &corev1.ObjectFieldSelector{
+			FieldPath: path,
+		},
+	}
+}
@@ -0,0 +1,167 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package utils
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"net"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	rsaKeySize = 2048
+
+	// validity of the generated certificates
+	certificateValidity = 24 * time.Hour
+)
+
+// Certificate contains a x509 certificate and the private key
+// used to sign it, in parsed and PEM encoded form.
+type Certificate struct {
+	Certificate *x509.Certificate
+	Key         *rsa.PrivateKey
+
+	CertificatePEM []byte
+	KeyPEM         []byte
+}
+
+// NewCA generates a self-signed certificate authority.
+func NewCA(commonName string) (*Certificate, error) {
+	template, err := certificateTemplate(commonName)
+	if err != nil {
+		return nil, err
+	}
+
+	template.IsCA = true
+	template.KeyUsage = x509.KeyUsageCertSign | x509.KeyUsageCRLSign | x509.KeyUsageDigitalSignature
+	template.BasicConstraintsValid = true
+
+	return newCertificate(template, nil)
+}
+
+// NewServerCertificate generates a certificate signed by the CA
+// valid for the specified hosts (DNS names or IP addresses).
+func NewServerCertificate(ca *Certificate, hosts ...string) (*Certificate, error) {
+	if ca == nil {
+		return nil, fmt.Errorf("a CA is required to sign a certificate")
+	}
+
+	if len(hosts) == 0 {
+		return nil, fmt.Errorf("at least one host is required")
+	}
+
+	template, err := certificateTemplate(hosts[0])
+	if err != nil {
+		return nil, err
+	}
+
+	template.KeyUsage = x509.KeyUsageKeyEncipherment | x509.KeyUsageDigitalSignature
+	template.ExtKeyUsage = []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth}
+
+	for _, host := range hosts {
+		if ip := net.ParseIP(host); ip != nil {
+			template.IPAddresses = append(template.IPAddresses, ip)
+		} else {
+			template.DNSNames = append(template.DNSNames, host)
+		}
+	}
+
+	return newCertificate(template, ca)
+}
+
+// CreateTLSSecret creates a secret of type kubernetes.io/tls containing the certificate.
+func CreateTLSSecret(c kubernetes.Interface, namespace, name string, cert *Certificate) error {
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: name,
+		},
+		Type: corev1.SecretTypeTLS,
+		Data: map[string][]byte{
+			corev1.TLSCertKey:       cert.CertificatePEM,
+			corev1.TLSPrivateKeyKey: cert.KeyPEM,
+		},
+	}
+
+	_, err := c.CoreV1().Secrets(namespace).Create(context.TODO(), secret, metav1.CreateOptions{})
+	if err != nil {
+		return fmt.Errorf("unable to create secret %v: %v", name, err)
+	}
+
+	return nil
+}
+
+func certificateTemplate(commonName string) (*x509.Certificate, error) {
+	serialNumberLimit := new(big.Int).Lsh(big.NewInt(1), 128)
+
+	serialNumber, err := rand.Int(rand.Reader, serialNumberLimit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate serial number: %v", err)
+	}
+
+	notBefore := time.Now().Add(-time.Hour)
+
+	return &x509.Certificate{
+		SerialNumber: serialNumber,
+		Subject: pkix.Name{
+			CommonName:   commonName,
+			Organization: []string{"Kubernetes Ingress conformance"},
+		},
+		NotBefore: notBefore,
+		NotAfter:  notBefore.Add(certificateValidity),
+	}, nil
+}
+
+// newCertificate signs the template using the CA or, if ca is nil, the new key.
+func newCertificate(template *x509.Certificate, ca *Certificate) (*Certificate, error) {
+	key, err := rsa.GenerateKey(rand.Reader, rsaKeySize)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate private key: %v", err)
+	}
+
+	parent, signer := template, key
+	if ca != nil {
+		parent, signer = ca.Certificate, ca.Key
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, &key.PublicKey, signer)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create certificate: %v", err)
+	}
+
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Certificate{
+		Certificate:    cert,
+		Key:            key,
+		CertificatePEM: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		KeyPEM:         pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)}),
+	}, nil
+}
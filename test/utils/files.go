@@ -18,6 +18,7 @@ package utils
 
 import (
 	"fmt"
+	"io/fs"
 	"io/ioutil"
 	"os"
 )
@@ -57,3 +58,13 @@ func IsDir(path string) bool {
 
 	return info.IsDir()
 }
+
+// isDirFS returns true if the path is a directory in the filesystem
+func isDirFS(fsys fs.FS, path string) bool {
+	info, err := fs.Stat(fsys, path)
+	if err != nil {
+		return false
+	}
+
+	return info.IsDir()
+}
@@ -0,0 +1,320 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package utils
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+
+	networkingv1 "k8s.io/api/networking/v1"
+	networkingv1beta1 "k8s.io/api/networking/v1beta1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/kubernetes"
+)
+
+var (
+	// IngressAPIVersion is the API version used to create and read Ingresses.
+	// The value is updated by DetectIngressAPIVersion before running the suite.
+	IngressAPIVersion = networkingv1beta1.SchemeGroupVersion.String()
+
+	// IngressHTTPPort is the port of the HTTP requests sent to the address
+	// of the Ingresses. The default port (80) is used when zero.
+	IngressHTTPPort = 0
+	// IngressHTTPSPort is the port of the HTTPS requests sent to the address
+	// of the Ingresses. The default port (443) is used when zero.
+	IngressHTTPSPort = 0
+)
+
+// ingressAPIVersions contains the Ingress API versions supported by
+// the suite, in order of preference.
+var ingressAPIVersions = []string{
+	networkingv1.SchemeGroupVersion.String(),
+	networkingv1beta1.SchemeGroupVersion.String(),
+}
+
+// DetectIngressAPIVersion uses discovery to locate the preferred Ingress API
+// version served by the cluster and returns all the versions found.
+func DetectIngressAPIVersion(dc discovery.DiscoveryInterface) ([]string, error) {
+	var served []string
+
+	for _, groupVersion := range ingressAPIVersions {
+		resources, err := dc.ServerResourcesForGroupVersion(groupVersion)
+		if err != nil {
+			if apierrors.IsNotFound(err) {
+				continue
+			}
+
+			return nil, fmt.Errorf("unexpected error discovering resources in %v: %v", groupVersion, err)
+		}
+
+		for _, resource := range resources.APIResources {
+			if resource.Name == "ingresses" {
+				served = append(served, groupVersion)
+				break
+			}
+		}
+	}
+
+	if len(served) == 0 {
+		return nil, fmt.Errorf("the cluster does not serve Ingress in any of the supported versions (%v)",
+			strings.Join(ingressAPIVersions, ", "))
+	}
+
+	IngressAPIVersion = served[0]
+
+	return served, nil
+}
+
+// IngressURL returns the URL of a request sent to the address of an Ingress
+// (status.loadBalancer), adding the port configured for the scheme
+func IngressURL(scheme, address, path string) string {
+	port := IngressHTTPPort
+	if scheme == "https" {
+		port = IngressHTTPSPort
+	}
+
+	if port != 0 {
+		address = net.JoinHostPort(address, strconv.Itoa(port))
+	}
+
+	return fmt.Sprintf("%v://%v%v", scheme, address, path)
+}
+
+// IsIngressV1 returns true if Ingresses are created using networking.k8s.io/v1
+func IsIngressV1() bool {
+	return IngressAPIVersion == networkingv1.SchemeGroupVersion.String()
+}
+
+// ingressV1FieldReplacer translates networking/v1beta1 Ingress field paths to networking/v1.
+// The full paths of spec.backend are listed before the generic rules because
+// the replacer uses the first match in argument order.
+var ingressV1FieldReplacer = strings.NewReplacer(
+	"spec.backend.serviceName", "spec.defaultBackend.service.name",
+	"spec.backend.servicePort", "spec.defaultBackend.service.port.number",
+	"spec.backend", "spec.defaultBackend",
+	"backend.serviceName", "backend.service.name",
+	"backend.servicePort", "backend.service.port.number",
+)
+
+// IngressV1FieldPath returns the text with networking/v1beta1 Ingress field
+// paths (like backend.serviceName) replaced by the networking/v1 equivalent.
+func IngressV1FieldPath(text string) string {
+	return ingressV1FieldReplacer.Replace(text)
+}
+
+// GetIngress returns the Ingress with the specified name using the
+// version returned by IngressAPIVersion.
+func GetIngress(c kubernetes.Interface, namespace, name string) (*networkingv1.Ingress, error) {
+	if IsIngressV1() {
+		return c.NetworkingV1().Ingresses(namespace).Get(context.TODO(), name, metav1.GetOptions{})
+	}
+
+	ing, err := c.NetworkingV1beta1().Ingresses(namespace).Get(context.TODO(), name, metav1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	return IngressFromV1beta1(ing), nil
+}
+
+func createIngress(c kubernetes.Interface, namespace string, ing *networkingv1.Ingress) (*networkingv1.Ingress, error) {
+	if IsIngressV1() {
+		return c.NetworkingV1().Ingresses(namespace).Create(context.TODO(), withPathType(ing), metav1.CreateOptions{})
+	}
+
+	created, err := c.NetworkingV1beta1().Ingresses(namespace).Create(context.TODO(), IngressToV1beta1(ing), metav1.CreateOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	return IngressFromV1beta1(created), nil
+}
+
+// withPathType returns a copy of the Ingress where paths without pathType use
+// ImplementationSpecific. The field is required in networking/v1, while the
+// API server sets the same default for networking/v1beta1 Ingresses.
+func withPathType(ing *networkingv1.Ingress) *networkingv1.Ingress {
+	ing = ing.DeepCopy()
+
+	for _, rule := range ing.Spec.Rules {
+		if rule.HTTP == nil {
+			continue
+		}
+
+		for i := range rule.HTTP.Paths {
+			if rule.HTTP.Paths[i].PathType == nil {
+				pathType := networkingv1.PathTypeImplementationSpecific
+				rule.HTTP.Paths[i].PathType = &pathType
+			}
+		}
+	}
+
+	return ing
+}
+
+// IngressFromV1beta1 converts a networking/v1beta1 Ingress to networking/v1
+func IngressFromV1beta1(in *networkingv1beta1.Ingress) *networkingv1.Ingress {
+	out := &networkingv1.Ingress{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "Ingress",
+			APIVersion: networkingv1.SchemeGroupVersion.String(),
+		},
+		ObjectMeta: *in.ObjectMeta.DeepCopy(),
+		Spec: networkingv1.IngressSpec{
+			IngressClassName: in.Spec.IngressClassName,
+			DefaultBackend:   backendFromV1beta1(in.Spec.Backend),
+		},
+		Status: networkingv1.IngressStatus{
+			LoadBalancer: *in.Status.LoadBalancer.DeepCopy(),
+		},
+	}
+
+	for _, tls := range in.Spec.TLS {
+		out.Spec.TLS = append(out.Spec.TLS, networkingv1.IngressTLS{
+			Hosts:      append([]string(nil), tls.Hosts...),
+			SecretName: tls.SecretName,
+		})
+	}
+
+	for _, rule := range in.Spec.Rules {
+		r := networkingv1.IngressRule{Host: rule.Host}
+		if rule.HTTP != nil {
+			r.HTTP = &networkingv1.HTTPIngressRuleValue{}
+			for _, path := range rule.HTTP.Paths {
+				var pathType *networkingv1.PathType
+				if path.PathType != nil {
+					pt := networkingv1.PathType(*path.PathType)
+					pathType = &pt
+				}
+
+				r.HTTP.Paths = append(r.HTTP.Paths, networkingv1.HTTPIngressPath{
+					Path:     path.Path,
+					PathType: pathType,
+					Backend:  *backendFromV1beta1(&path.Backend),
+				})
+			}
+		}
+
+		out.Spec.Rules = append(out.Spec.Rules, r)
+	}
+
+	return out
+}
+
+// IngressToV1beta1 converts a networking/v1 Ingress to networking/v1beta1
+func IngressToV1beta1(in *networkingv1.Ingress) *networkingv1beta1.Ingress {
+	out := &networkingv1beta1.Ingress{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "Ingress",
+			APIVersion: networkingv1beta1.SchemeGroupVersion.String(),
+		},
+		ObjectMeta: *in.ObjectMeta.DeepCopy(),
+		Spec: networkingv1beta1.IngressSpec{
+			IngressClassName: in.Spec.IngressClassName,
+			Backend:          backendToV1beta1(in.Spec.DefaultBackend),
+		},
+		Status: networkingv1beta1.IngressStatus{
+			LoadBalancer: *in.Status.LoadBalancer.DeepCopy(),
+		},
+	}
+
+	for _, tls := range in.Spec.TLS {
+		out.Spec.TLS = append(out.Spec.TLS, networkingv1beta1.IngressTLS{
+			Hosts:      append([]string(nil), tls.Hosts...),
+			SecretName: tls.SecretName,
+		})
+	}
+
+	for _, rule := range in.Spec.Rules {
+		r := networkingv1beta1.IngressRule{Host: rule.Host}
+		if rule.HTTP != nil {
+			r.HTTP = &networkingv1beta1.HTTPIngressRuleValue{}
+			for _, path := range rule.HTTP.Paths {
+				var pathType *networkingv1beta1.PathType
+				if path.PathType != nil {
+					pt := networkingv1beta1.PathType(*path.PathType)
+					pathType = &pt
+				}
+
+				r.HTTP.Paths = append(r.HTTP.Paths, networkingv1beta1.HTTPIngressPath{
+					Path:     path.Path,
+					PathType: pathType,
+					Backend:  *backendToV1beta1(&path.Backend),
+				})
+			}
+		}
+
+		out.Spec.Rules = append(out.Spec.Rules, r)
+	}
+
+	return out
+}
+
+func backendFromV1beta1(in *networkingv1beta1.IngressBackend) *networkingv1.IngressBackend {
+	if in == nil {
+		return nil
+	}
+
+	out := &networkingv1.IngressBackend{
+		Resource: in.Resource.DeepCopy(),
+	}
+
+	if in.ServiceName == "" && in.ServicePort.IntValue() == 0 && in.ServicePort.StrVal == "" {
+		return out
+	}
+
+	out.Service = &networkingv1.IngressServiceBackend{
+		Name: in.ServiceName,
+	}
+
+	if in.ServicePort.Type == intstr.String {
+		out.Service.Port.Name = in.ServicePort.StrVal
+	} else {
+		out.Service.Port.Number = in.ServicePort.IntVal
+	}
+
+	return out
+}
+
+func backendToV1beta1(in *networkingv1.IngressBackend) *networkingv1beta1.IngressBackend {
+	if in == nil {
+		return nil
+	}
+
+	out := &networkingv1beta1.IngressBackend{
+		Resource: in.Resource.DeepCopy(),
+	}
+
+	if in.Service == nil {
+		return out
+	}
+
+	out.ServiceName = in.Service.Name
+	if in.Service.Port.Name != "" {
+		out.ServicePort = intstr.FromString(in.Service.Port.Name)
+	} else {
+		out.ServicePort = intstr.FromInt(int(in.Service.Port.Number))
+	}
+
+	return out
+}
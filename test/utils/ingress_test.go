@@ -0,0 +1,249 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package utils
+
+import (
+	"reflect"
+	"testing"
+
+	networkingv1 "k8s.io/api/networking/v1"
+	networkingv1beta1 "k8s.io/api/networking/v1beta1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestIngressV1FieldPath(t *testing.T) {
+	testCases := []struct {
+		name     string
+		text     string
+		expected string
+	}{
+		{
+			name:     "rule backend serviceName",
+			text:     "spec.rules[0].http.paths[0].backend.serviceName: Required value",
+			expected: "spec.rules[0].http.paths[0].backend.service.name: Required value",
+		},
+		{
+			name:     "rule backend servicePort",
+			text:     "spec.rules[0].http.paths[0].backend.servicePort: Invalid value",
+			expected: "spec.rules[0].http.paths[0].backend.service.port.number: Invalid value",
+		},
+		{
+			name:     "default backend serviceName",
+			text:     "spec.backend.serviceName: Required value",
+			expected: "spec.defaultBackend.service.name: Required value",
+		},
+		{
+			name:     "default backend servicePort",
+			text:     "spec.backend.servicePort: Invalid value",
+			expected: "spec.defaultBackend.service.port.number: Invalid value",
+		},
+		{
+			name:     "default backend",
+			text:     "spec.backend: Invalid value",
+			expected: "spec.defaultBackend: Invalid value",
+		},
+		{
+			name:     "unrelated text",
+			text:     "spec.rules[0].host: Invalid value",
+			expected: "spec.rules[0].host: Invalid value",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := IngressV1FieldPath(tc.text); got != tc.expected {
+				t.Errorf("expected %q but returned %q", tc.expected, got)
+			}
+		})
+	}
+}
+
+func TestIngressPathTypeConversion(t *testing.T) {
+	exact := networkingv1beta1.PathTypeExact
+
+	in := &networkingv1beta1.Ingress{
+		Spec: networkingv1beta1.IngressSpec{
+			Rules: []networkingv1beta1.IngressRule{
+				{
+					Host: "foo.bar",
+					IngressRuleValue: networkingv1beta1.IngressRuleValue{
+						HTTP: &networkingv1beta1.HTTPIngressRuleValue{
+							Paths: []networkingv1beta1.HTTPIngressPath{
+								{Path: "/"},
+								{Path: "/exact", PathType: &exact},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	ing := IngressFromV1beta1(in)
+	paths := ing.Spec.Rules[0].HTTP.Paths
+	if paths[0].PathType != nil {
+		t.Errorf("expected a nil pathType but got %v", *paths[0].PathType)
+	}
+
+	if paths[1].PathType == nil || *paths[1].PathType != networkingv1.PathTypeExact {
+		t.Errorf("expected pathType Exact but got %v", paths[1].PathType)
+	}
+
+	// the round trip keeps the pathType of the source
+	out := IngressToV1beta1(ing).Spec.Rules[0].HTTP.Paths
+	if out[0].PathType != nil {
+		t.Errorf("expected a nil pathType after the round trip but got %v", *out[0].PathType)
+	}
+
+	if out[1].PathType == nil || *out[1].PathType != exact {
+		t.Errorf("expected pathType Exact after the round trip but got %v", out[1].PathType)
+	}
+
+	// networking/v1 requires the field
+	defaulted := withPathType(ing).Spec.Rules[0].HTTP.Paths
+	if defaulted[0].PathType == nil || *defaulted[0].PathType != networkingv1.PathTypeImplementationSpecific {
+		t.Errorf("expected pathType ImplementationSpecific but got %v", defaulted[0].PathType)
+	}
+
+	if paths[0].PathType != nil {
+		t.Errorf("expected the Ingress without changes")
+	}
+}
+
+func TestPrepareObject(t *testing.T) {
+	apiVersion, classValue, useClass := IngressAPIVersion, IngressClassValue, UseIngressClass
+	defer func() {
+		IngressAPIVersion, IngressClassValue, UseIngressClass = apiVersion, classValue, useClass
+	}()
+
+	IngressAPIVersion = networkingv1.SchemeGroupVersion.String()
+	IngressClassValue = "conformance"
+	UseIngressClass = false
+
+	ingress := func(apiVersion string) *unstructured.Unstructured {
+		return &unstructured.Unstructured{Object: map[string]interface{}{
+			"apiVersion": apiVersion,
+			"kind":       "Ingress",
+			"metadata":   map[string]interface{}{"name": "echo"},
+			"spec": map[string]interface{}{
+				"backend": map[string]interface{}{"serviceName": "echo", "servicePort": int64(80)},
+			},
+		}}
+	}
+
+	ingressClass := func(apiVersion string) *unstructured.Unstructured {
+		return &unstructured.Unstructured{Object: map[string]interface{}{
+			"apiVersion": apiVersion,
+			"kind":       "IngressClass",
+			"metadata":   map[string]interface{}{"name": "conformance"},
+			"spec":       map[string]interface{}{"controller": "example.com/controller"},
+		}}
+	}
+
+	testCases := []struct {
+		name      string
+		obj       *unstructured.Unstructured
+		converted bool
+	}{
+		{
+			name:      "networking.k8s.io Ingress",
+			obj:       ingress(networkingv1beta1.SchemeGroupVersion.String()),
+			converted: true,
+		},
+		{
+			name:      "extensions Ingress",
+			obj:       ingress("extensions/v1beta1"),
+			converted: true,
+		},
+		{
+			name: "custom resource of kind Ingress",
+			obj:  ingress("example.com/v1"),
+		},
+		{
+			name:      "networking.k8s.io IngressClass",
+			obj:       ingressClass(networkingv1beta1.SchemeGroupVersion.String()),
+			converted: true,
+		},
+		{
+			name: "custom resource of kind IngressClass",
+			obj:  ingressClass("example.com/v1"),
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			obj, err := prepareObject("test", tc.obj)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if !tc.converted {
+				if !reflect.DeepEqual(obj, tc.obj) {
+					t.Errorf("expected the object without changes but got %v", obj.Object)
+				}
+
+				return
+			}
+
+			if obj.GetAPIVersion() != networkingv1.SchemeGroupVersion.String() {
+				t.Errorf("expected apiVersion %v but got %v", networkingv1.SchemeGroupVersion, obj.GetAPIVersion())
+			}
+
+			switch obj.GetKind() {
+			case "Ingress":
+				if class := obj.GetAnnotations()[IngressClassKey]; class != "conformance" {
+					t.Errorf("expected Ingress with class conformance but got %v", class)
+				}
+
+				if name, _, _ := unstructured.NestedString(obj.Object, "spec", "defaultBackend", "service", "name"); name != "echo" {
+					t.Errorf("expected the default backend echo but got %v", obj.Object["spec"])
+				}
+			case "IngressClass":
+				if namespace := obj.GetLabels()[ScenarioNamespaceLabel]; namespace != "test" {
+					t.Errorf("expected IngressClass with label %v=test but got %v", ScenarioNamespaceLabel, obj.GetLabels())
+				}
+			}
+		})
+	}
+}
+
+func TestOrderOf(t *testing.T) {
+	testCases := []struct {
+		apiVersion string
+		kind       string
+		expected   int
+	}{
+		{apiVersion: "apiextensions.k8s.io/v1", kind: "CustomResourceDefinition", expected: 0},
+		{apiVersion: "networking.k8s.io/v1", kind: "IngressClass", expected: 1},
+		{apiVersion: "v1", kind: "Secret", expected: 2},
+		{apiVersion: "v1", kind: "Service", expected: 3},
+		{apiVersion: "apps/v1", kind: "Deployment", expected: 4},
+		{apiVersion: "networking.k8s.io/v1", kind: "Ingress", expected: defaultKindOrder},
+		{apiVersion: "example.com/v1", kind: "IngressClass", expected: defaultKindOrder},
+		{apiVersion: "example.com/v1", kind: "Service", expected: defaultKindOrder},
+	}
+
+	for _, tc := range testCases {
+		obj := &unstructured.Unstructured{}
+		obj.SetAPIVersion(tc.apiVersion)
+		obj.SetKind(tc.kind)
+
+		if order := orderOf(obj); order != tc.expected {
+			t.Errorf("expected order %v for %v %v but got %v", tc.expected, tc.apiVersion, tc.kind, order)
+		}
+	}
+}
@@ -0,0 +1,303 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package utils
+
+import (
+	"context"
+	"fmt"
+
+	networkingv1 "k8s.io/api/networking/v1"
+	networkingv1beta1 "k8s.io/api/networking/v1beta1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	// IngressClassDefaultAnnotation indicates the IngressClass should be
+	// considered the default for the cluster
+	IngressClassDefaultAnnotation = "ingressclass.kubernetes.io/is-default-class"
+)
+
+var (
+	// UseIngressClass configures the suite to create an IngressClass and
+	// set spec.ingressClassName instead of the kubernetes.io/ingress.class annotation
+	UseIngressClass = false
+
+	// IngressControllerName sets the value of spec.controller in the IngressClass
+	IngressControllerName = ""
+
+	// suiteIngressClassCreated is true when EnsureIngressClass created the
+	// IngressClass used by the suite, removed by DeleteSuiteIngressClass
+	suiteIngressClassCreated = false
+)
+
+// GetIngressClass returns the IngressClass with the specified name.
+func GetIngressClass(c kubernetes.Interface, name string) (*networkingv1.IngressClass, error) {
+	if IsIngressV1() {
+		return c.NetworkingV1().IngressClasses().Get(context.TODO(), name, metav1.GetOptions{})
+	}
+
+	class, err := c.NetworkingV1beta1().IngressClasses().Get(context.TODO(), name, metav1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	return ingressClassFromV1beta1(class), nil
+}
+
+// ListIngressClasses returns all the IngressClasses defined in the cluster.
+func ListIngressClasses(c kubernetes.Interface) ([]networkingv1.IngressClass, error) {
+	if IsIngressV1() {
+		classes, err := c.NetworkingV1().IngressClasses().List(context.TODO(), metav1.ListOptions{})
+		if err != nil {
+			return nil, err
+		}
+
+		return classes.Items, nil
+	}
+
+	classes, err := c.NetworkingV1beta1().IngressClasses().List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	var items []networkingv1.IngressClass
+	for i := range classes.Items {
+		items = append(items, *ingressClassFromV1beta1(&classes.Items[i]))
+	}
+
+	return items, nil
+}
+
+// CreateIngressClass creates an IngressClass for the specified controller.
+// If name is empty, a random name with the prefix ingress-conformance- is used.
+func CreateIngressClass(c kubernetes.Interface, name, controller string) (*networkingv1.IngressClass, error) {
+	class := &networkingv1.IngressClass{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: name,
+			Labels: map[string]string{
+				"app.kubernetes.io/name": "ingress-conformance",
+			},
+		},
+		Spec: networkingv1.IngressClassSpec{
+			Controller: controller,
+		},
+	}
+
+	if name == "" {
+		class.GenerateName = "ingress-conformance-"
+	}
+
+	return createIngressClass(c, class)
+}
+
+// createIngressClass creates the IngressClass using the version returned by IngressAPIVersion.
+func createIngressClass(c kubernetes.Interface, class *networkingv1.IngressClass) (*networkingv1.IngressClass, error) {
+	if IsIngressV1() {
+		return c.NetworkingV1().IngressClasses().Create(context.TODO(), class, metav1.CreateOptions{})
+	}
+
+	created, err := c.NetworkingV1beta1().IngressClasses().Create(context.TODO(), ingressClassToV1beta1(class), metav1.CreateOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	return ingressClassFromV1beta1(created), nil
+}
+
+// DeleteIngressClass deletes the IngressClass with the specified name.
+func DeleteIngressClass(c kubernetes.Interface, name string) error {
+	var err error
+	if IsIngressV1() {
+		err = c.NetworkingV1().IngressClasses().Delete(context.TODO(), name, metav1.DeleteOptions{})
+	} else {
+		err = c.NetworkingV1beta1().IngressClasses().Delete(context.TODO(), name, metav1.DeleteOptions{})
+	}
+
+	if apierrors.IsNotFound(err) {
+		return nil
+	}
+
+	return err
+}
+
+// CleanupIngressClasses deletes the IngressClasses created from the manifests of a
+// scenario, identified by the label ScenarioNamespaceLabel.
+func CleanupIngressClasses(c kubernetes.Interface, namespace string) error {
+	classes, err := ListIngressClasses(c)
+	if err != nil {
+		return err
+	}
+
+	for _, class := range classes {
+		if class.Labels[ScenarioNamespaceLabel] != namespace {
+			continue
+		}
+
+		if err := DeleteIngressClass(c, class.Name); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// SetDefaultIngressClass sets the value of the annotation ingressclass.kubernetes.io/is-default-class
+// in the IngressClass with the specified name, removing the annotation if the value is empty.
+// The previous value is returned, allowing to restore the IngressClass.
+func SetDefaultIngressClass(c kubernetes.Interface, name, value string) (string, error) {
+	class, err := GetIngressClass(c, name)
+	if err != nil {
+		return "", err
+	}
+
+	if class.Annotations == nil {
+		class.Annotations = map[string]string{}
+	}
+
+	previous := class.Annotations[IngressClassDefaultAnnotation]
+
+	if value != "" {
+		class.Annotations[IngressClassDefaultAnnotation] = value
+	} else {
+		delete(class.Annotations, IngressClassDefaultAnnotation)
+	}
+
+	if IsIngressV1() {
+		_, err = c.NetworkingV1().IngressClasses().Update(context.TODO(), class, metav1.UpdateOptions{})
+		return previous, err
+	}
+
+	_, err = c.NetworkingV1beta1().IngressClasses().Update(context.TODO(), ingressClassToV1beta1(class), metav1.UpdateOptions{})
+	return previous, err
+}
+
+// IsDefaultIngressClass returns true if the IngressClass is annotated as default.
+func IsDefaultIngressClass(class *networkingv1.IngressClass) bool {
+	return class.Annotations[IngressClassDefaultAnnotation] == "true"
+}
+
+// EnsureIngressClass verifies the IngressClass used by the suite exists and
+// is handled by the configured controller, creating it when it is missing.
+// An IngressClass created by the suite is removed using DeleteSuiteIngressClass.
+func EnsureIngressClass(c kubernetes.Interface) error {
+	if IngressControllerName == "" {
+		return fmt.Errorf("the name of the ingress controller is required to use an IngressClass")
+	}
+
+	class, err := GetIngressClass(c, IngressClassValue)
+	if apierrors.IsNotFound(err) {
+		_, err = CreateIngressClass(c, IngressClassValue, IngressControllerName)
+		if err == nil {
+			suiteIngressClassCreated = true
+		}
+
+		return err
+	}
+
+	if err != nil {
+		return err
+	}
+
+	if class.Spec.Controller != IngressControllerName {
+		return fmt.Errorf("IngressClass %v exists but is handled by controller %v (expected %v)",
+			class.Name, class.Spec.Controller, IngressControllerName)
+	}
+
+	return nil
+}
+
+// DeleteSuiteIngressClass deletes the IngressClass used by the suite
+// if it was created by EnsureIngressClass. Existing classes are kept.
+func DeleteSuiteIngressClass(c kubernetes.Interface) error {
+	if !suiteIngressClassCreated {
+		return nil
+	}
+
+	if err := DeleteIngressClass(c, IngressClassValue); err != nil {
+		return err
+	}
+
+	suiteIngressClassCreated = false
+
+	return nil
+}
+
+// SetIngressClass configures the class of the Ingress using the
+// annotation kubernetes.io/ingress.class or spec.ingressClassName
+func SetIngressClass(ing *networkingv1.Ingress) {
+	if IngressClassValue == "" {
+		return
+	}
+
+	if ing.Annotations == nil {
+		ing.Annotations = map[string]string{}
+	}
+
+	if UseIngressClass {
+		class := IngressClassValue
+		ing.Spec.IngressClassName = &class
+		delete(ing.Annotations, IngressClassKey)
+		return
+	}
+
+	ing.Annotations[IngressClassKey] = IngressClassValue
+}
+
+// checkIngressClass verifies the Ingress is using the class configured in the suite
+func checkIngressClass(ing *networkingv1.Ingress) error {
+	if UseIngressClass {
+		if ing.Spec.IngressClassName == nil {
+			return fmt.Errorf("Ingress with name %v does not define spec.ingressClassName", ing.Name)
+		}
+
+		if *ing.Spec.IngressClassName != IngressClassValue {
+			return fmt.Errorf("Ingress with name %v has an invalid spec.ingressClassName (%v)",
+				ing.Name, *ing.Spec.IngressClassName)
+		}
+
+		return nil
+	}
+
+	class := ing.Annotations[IngressClassKey]
+	if class != IngressClassValue {
+		return fmt.Errorf("Ingress with name %v has an invalid annotation (%v)", ing.Name, class)
+	}
+
+	return nil
+}
+
+func ingressClassFromV1beta1(in *networkingv1beta1.IngressClass) *networkingv1.IngressClass {
+	return &networkingv1.IngressClass{
+		ObjectMeta: *in.ObjectMeta.DeepCopy(),
+		Spec: networkingv1.IngressClassSpec{
+			Controller: in.Spec.Controller,
+			Parameters: in.Spec.Parameters.DeepCopy(),
+		},
+	}
+}
+
+func ingressClassToV1beta1(in *networkingv1.IngressClass) *networkingv1beta1.IngressClass {
+	return &networkingv1beta1.IngressClass{
+		ObjectMeta: *in.ObjectMeta.DeepCopy(),
+		Spec: networkingv1beta1.IngressClassSpec{
+			Controller: in.Spec.Controller,
+			Parameters: in.Spec.Parameters.DeepCopy(),
+		},
+	}
+}
@@ -0,0 +1,130 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package utils
+
+import (
+	"testing"
+
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestSetDefaultIngressClass(t *testing.T) {
+	restoreIngressClassGlobals(t)
+
+	IngressAPIVersion = networkingv1.SchemeGroupVersion.String()
+
+	c := fake.NewSimpleClientset(&networkingv1.IngressClass{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "conformance",
+			Annotations: map[string]string{IngressClassDefaultAnnotation: "false"},
+		},
+	})
+
+	previous, err := SetDefaultIngressClass(c, "conformance", "true")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if previous != "false" {
+		t.Errorf("expected the previous value false but got %q", previous)
+	}
+
+	class, _ := GetIngressClass(c, "conformance")
+	if !IsDefaultIngressClass(class) {
+		t.Errorf("expected the IngressClass marked as default")
+	}
+
+	// restoring the previous value
+	if _, err := SetDefaultIngressClass(c, "conformance", previous); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	class, _ = GetIngressClass(c, "conformance")
+	if value, ok := class.Annotations[IngressClassDefaultAnnotation]; !ok || value != "false" {
+		t.Errorf("expected the annotation restored to false but got %q", value)
+	}
+
+	// an empty value removes the annotation
+	if _, err := SetDefaultIngressClass(c, "conformance", ""); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	class, _ = GetIngressClass(c, "conformance")
+	if _, ok := class.Annotations[IngressClassDefaultAnnotation]; ok {
+		t.Errorf("expected the annotation removed")
+	}
+}
+
+func TestDeleteSuiteIngressClass(t *testing.T) {
+	restoreIngressClassGlobals(t)
+
+	IngressAPIVersion = networkingv1.SchemeGroupVersion.String()
+	IngressClassValue = "conformance"
+	IngressControllerName = "example.com/ingress-controller"
+
+	testCases := []struct {
+		name    string
+		objects []*networkingv1.IngressClass
+		deleted bool
+	}{
+		{"created by the suite", nil, true},
+		{"existing class", []*networkingv1.IngressClass{
+			{
+				ObjectMeta: metav1.ObjectMeta{Name: "conformance"},
+				Spec:       networkingv1.IngressClassSpec{Controller: IngressControllerName},
+			},
+		}, false},
+	}
+
+	for _, tc := range testCases {
+		c := fake.NewSimpleClientset()
+		for _, class := range tc.objects {
+			_ = c.Tracker().Add(class)
+		}
+
+		if err := EnsureIngressClass(c); err != nil {
+			t.Fatalf("%v: unexpected error: %v", tc.name, err)
+		}
+
+		if err := DeleteSuiteIngressClass(c); err != nil {
+			t.Fatalf("%v: unexpected error: %v", tc.name, err)
+		}
+
+		_, err := GetIngressClass(c, "conformance")
+		if deleted := err != nil; deleted != tc.deleted {
+			t.Errorf("%v: expected IngressClass deleted %v but got %v (%v)", tc.name, tc.deleted, deleted, err)
+		}
+	}
+}
+
+// restoreIngressClassGlobals restores the configuration of the IngressClass
+// used by the suite when the test finishes
+func restoreIngressClassGlobals(t *testing.T) {
+	ingressAPIVersion := IngressAPIVersion
+	ingressClassValue := IngressClassValue
+	ingressControllerName := IngressControllerName
+	created := suiteIngressClassCreated
+
+	t.Cleanup(func() {
+		IngressAPIVersion = ingressAPIVersion
+		IngressClassValue = ingressClassValue
+		IngressControllerName = ingressControllerName
+		suiteIngressClassCreated = created
+	})
+}
@@ -3,10 +3,11 @@ package utils
 import (
 	"context"
 	"fmt"
+	"os"
 	"time"
 
 	corev1 "k8s.io/api/core/v1"
-	v1beta1 "k8s.io/api/networking/v1beta1"
+	networkingv1 "k8s.io/api/networking/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	apierrs "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -27,12 +28,20 @@ const (
 	NamespaceCleanupTimeout = 5 * time.Minute
 	// WaitForIngressAddressTimeout wait time for valid ingress status
 	WaitForIngressAddressTimeout = 5 * time.Minute
-	// IngressWaitInterval time to wait between checks for a condition
-	IngressWaitInterval = 5 * time.Second
 )
 
+const (
+	// NamespaceNameLabel identifies the namespaces created by conformance tests
+	NamespaceNameLabel = "app.kubernetes.io/name"
+	// PodNamespaceEnv contains the namespace of the pod running the suite (downward API)
+	PodNamespaceEnv = "POD_NAMESPACE"
+)
+
+// IngressWaitInterval time to wait between checks for a condition
+var IngressWaitInterval = 5 * time.Second
+
 // KubeClient Kubernetes API client
-var KubeClient *kubernetes.Clientset
+var KubeClient kubernetes.Interface
 
 // WaitForService waits until the service appears (exist == true), or disappears (exist == false)
 func WaitForService(c clientset.Interface, namespace, name string, exist bool, interval, timeout time.Duration) error {
@@ -65,7 +74,7 @@ func WaitForService(c clientset.Interface, namespace, name string, exist bool, i
 //WaitForServiceEndpointsNum waits until the amount of endpoints that implement service to expectNum.
 func WaitForServiceEndpointsNum(c clientset.Interface, namespace, serviceName string,
 	expectNum int, interval, timeout time.Duration) error {
-	return wait.Poll(interval, timeout, func() (bool, error) {
+	return wait.PollImmediate(interval, timeout, func() (bool, error) {
 		list, err := c.CoreV1().Endpoints(namespace).List(context.TODO(), metav1.ListOptions{})
 		if err != nil {
 			return false, err
@@ -90,20 +99,26 @@ func countEndpointsNum(e *corev1.Endpoints) int {
 	return num
 }
 
-// LoadClientset returns clientset for connecting to kubernetes clusters.
-func LoadClientset() (*clientset.Clientset, error) {
+// LoadConfig returns the configuration for connecting to kubernetes clusters.
+// The in-cluster configuration is used if available, otherwise the local KUBECONFIG.
+func LoadConfig() (*restclient.Config, error) {
 	config, err := restclient.InClusterConfig()
 	if err != nil {
 		// Attempt to use local KUBECONFIG
 		loadingRules := clientcmd.NewDefaultClientConfigLoadingRules()
 		kubeconfig := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(loadingRules, &clientcmd.ConfigOverrides{})
 		// use the current context in kubeconfig
-		var err error
+		return kubeconfig.ClientConfig()
+	}
 
-		config, err = kubeconfig.ClientConfig()
-		if err != nil {
-			return nil, err
-		}
+	return config, nil
+}
+
+// LoadClientset returns clientset for connecting to kubernetes clusters.
+func LoadClientset() (*clientset.Clientset, error) {
+	config, err := LoadConfig()
+	if err != nil {
+		return nil, err
 	}
 
 	client, err := clientset.NewForConfig(config)
@@ -121,7 +136,7 @@ func CreateTestNamespace(c kubernetes.Interface) (string, error) {
 		ObjectMeta: metav1.ObjectMeta{
 			GenerateName: "ingress-conformance-",
 			Labels: map[string]string{
-				"app.kubernetes.io/name": "ingress-conformance",
+				NamespaceNameLabel: "ingress-conformance",
 			},
 		},
 	}
@@ -147,18 +162,37 @@ func DeleteKubeNamespace(c kubernetes.Interface, namespace string) error {
 	})
 }
 
-// CleanupNamespaces removes namespaces created by conformance tests
-func CleanupNamespaces(c kubernetes.Interface) error {
+// TestNamespaces returns the namespaces created by conformance tests. The
+// namespace where the suite runs (env POD_NAMESPACE) is never included.
+func TestNamespaces(c kubernetes.Interface) ([]string, error) {
 	namespaces, err := c.CoreV1().Namespaces().List(context.TODO(), metav1.ListOptions{
-		LabelSelector: "app.kubernetes.io/name=ingress-conformance",
+		LabelSelector: fmt.Sprintf("%v=ingress-conformance", NamespaceNameLabel),
 	})
+	if err != nil {
+		return nil, err
+	}
+
+	var names []string
+	for _, namespace := range namespaces.Items {
+		if namespace.Name == os.Getenv(PodNamespaceEnv) {
+			continue
+		}
+
+		names = append(names, namespace.Name)
+	}
 
+	return names, nil
+}
+
+// CleanupNamespaces removes namespaces created by conformance tests
+func CleanupNamespaces(c kubernetes.Interface) error {
+	namespaces, err := TestNamespaces(c)
 	if err != nil {
 		return err
 	}
 
-	for _, namespace := range namespaces.Items {
-		err := DeleteKubeNamespace(c, namespace.Name)
+	for _, namespace := range namespaces {
+		err := DeleteKubeNamespace(c, namespace)
 		if err != nil {
 			return err
 		}
@@ -184,22 +218,23 @@ func IsRetryableAPIError(err error) bool {
 }
 
 // CreateIngress creates an Ingress object and retunrs it, throws error if it already exists.
-func CreateIngress(c kubernetes.Interface, ingress *v1beta1.Ingress) (*v1beta1.Ingress, error) {
+// The Ingress is created using the API version defined in IngressAPIVersion.
+func CreateIngress(c kubernetes.Interface, ingress *networkingv1.Ingress) (*networkingv1.Ingress, error) {
 	err := createIngressWithRetries(c, ingress.Namespace, ingress)
 	if err != nil {
 		return nil, err
 	}
 
-	return c.NetworkingV1beta1().Ingresses(ingress.Namespace).Get(context.TODO(), ingress.Name, metav1.GetOptions{})
+	return GetIngress(c, ingress.Namespace, ingress.Name)
 }
 
-func createIngressWithRetries(c kubernetes.Interface, namespace string, obj *v1beta1.Ingress) error {
+func createIngressWithRetries(c kubernetes.Interface, namespace string, obj *networkingv1.Ingress) error {
 	if obj == nil {
 		return fmt.Errorf("object provided to create is empty")
 	}
 
 	createFunc := func() (bool, error) {
-		_, err := c.NetworkingV1beta1().Ingresses(namespace).Create(context.TODO(), obj, metav1.CreateOptions{})
+		_, err := createIngress(c, namespace, obj)
 		if err == nil {
 			return true, nil
 		}
@@ -241,16 +276,47 @@ func WaitForIngressAddress(c clientset.Interface, ns, ingName string, timeout ti
 
 // getIngressAddress returns the ips/hostnames associated with the Ingress.
 func getIngressAddress(c clientset.Interface, ns, name string) ([]string, error) {
-	ing, err := c.NetworkingV1beta1().Ingresses(ns).Get(context.TODO(), name, metav1.GetOptions{})
+	ing, err := GetIngress(c, ns, name)
 	if err != nil {
 		return nil, err
 	}
 
-	class := ing.Annotations[IngressClassKey]
-	if class != IngressClassValue {
-		return nil, fmt.Errorf("Ingress with name %v has an invalid annotation (%v)", IngressClassValue, class)
+	if err := checkIngressClass(ing); err != nil {
+		return nil, err
 	}
 
+	return ingressStatusAddresses(ing), nil
+}
+
+// WaitForIngressWithoutAddress checks the Ingress does not acquire an address during the
+// specified period of time. This is expected for Ingresses not handled by the controller.
+func WaitForIngressWithoutAddress(c clientset.Interface, ns, ingName string, period time.Duration) error {
+	err := wait.PollImmediate(IngressWaitInterval, period, func() (bool, error) {
+		ing, err := GetIngress(c, ns, ingName)
+		if err != nil {
+			if IsRetryableAPIError(err) {
+				return false, nil
+			}
+
+			return false, err
+		}
+
+		if addresses := ingressStatusAddresses(ing); len(addresses) != 0 {
+			return false, fmt.Errorf("expected Ingress %v without address but it has %v", ingName, addresses)
+		}
+
+		return false, nil
+	})
+
+	if err == wait.ErrWaitTimeout {
+		return nil
+	}
+
+	return err
+}
+
+// ingressStatusAddresses returns the ips/hostnames present in the Ingress status.
+func ingressStatusAddresses(ing *networkingv1.Ingress) []string {
 	var addresses []string
 
 	for _, a := range ing.Status.LoadBalancer.Ingress {
@@ -263,7 +329,7 @@ func getIngressAddress(c clientset.Interface, ns, name string) ([]string, error)
 		}
 	}
 
-	return addresses, nil
+	return addresses
 }
 
 const (
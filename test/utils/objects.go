@@ -0,0 +1,509 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package utils
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	networkingv1 "k8s.io/api/networking/v1"
+	networkingv1beta1 "k8s.io/api/networking/v1beta1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/wait"
+	utilyaml "k8s.io/apimachinery/pkg/util/yaml"
+	"k8s.io/client-go/discovery/cached/memory"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+	restclient "k8s.io/client-go/rest"
+	"k8s.io/client-go/restmapper"
+)
+
+const (
+	// ScenarioNamespaceLabel identifies cluster scoped objects (IngressClasses)
+	// created from the manifests of the scenario running in the namespace.
+	ScenarioNamespaceLabel = "ingress-conformance/namespace"
+
+	// FieldManager is the name of the field manager used in server-side apply
+	FieldManager = "ingress-conformance"
+
+	// WaitForObjectsTimeout wait time for workloads and services to be ready
+	WaitForObjectsTimeout = 5 * time.Minute
+
+	// waitForMappingTimeout wait time for new kinds (CRDs) to be served
+	waitForMappingTimeout = 30 * time.Second
+)
+
+var (
+	crdKind           = schema.GroupKind{Group: "apiextensions.k8s.io", Kind: "CustomResourceDefinition"}
+	ingressKind       = schema.GroupKind{Group: networkingv1.GroupName, Kind: "Ingress"}
+	legacyIngressKind = schema.GroupKind{Group: "extensions", Kind: "Ingress"}
+	ingressClassKind  = schema.GroupKind{Group: networkingv1.GroupName, Kind: "IngressClass"}
+	serviceKind       = schema.GroupKind{Kind: "Service"}
+	deploymentKind    = schema.GroupKind{Group: "apps", Kind: "Deployment"}
+	rcKind            = schema.GroupKind{Kind: "ReplicationController"}
+)
+
+// creation order of the kinds. Objects referenced by others are created
+// first. Ingresses and custom resources are applied once the workloads
+// and services are ready. Kinds are matched with their API group, so
+// custom resources using the same kind (i.e. Ingress) are not affected.
+var kindOrder = map[schema.GroupKind]int{
+	crdKind:             0,
+	ingressClassKind:    1,
+	{Kind: "ConfigMap"}: 2,
+	{Kind: "Secret"}:    2,
+	serviceKind:         3,
+	deploymentKind:      4,
+	rcKind:              4,
+}
+
+// order of the kinds not defined in kindOrder
+const defaultKindOrder = 5
+
+// ObjectClient applies objects of any kind, including custom
+// resources, using the dynamic client and server-side apply.
+type ObjectClient struct {
+	client  kubernetes.Interface
+	dynamic dynamic.Interface
+	mapper  *restmapper.DeferredDiscoveryRESTMapper
+}
+
+// ObjectsClient used to create the objects defined in the manifests of the scenarios
+var ObjectsClient *ObjectClient
+
+// NewObjectClient returns a client that resolves the resources using discovery
+func NewObjectClient(config *restclient.Config, c kubernetes.Interface) (*ObjectClient, error) {
+	dc, err := dynamic.NewForConfig(config)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewObjectClientFromClients(c, dc), nil
+}
+
+// NewObjectClientFromClients returns a client using existing clients (i.e. fake clients)
+func NewObjectClientFromClients(c kubernetes.Interface, dc dynamic.Interface) *ObjectClient {
+	return &ObjectClient{
+		client:  c,
+		dynamic: dc,
+		mapper:  restmapper.NewDeferredDiscoveryRESTMapper(memory.NewMemCacheClient(c.Discovery())),
+	}
+}
+
+// ObjectsFromFile reads a .json/yaml file containing one or more
+// documents and returns the objects defined in them. The content
+// of the file is rendered as a template using the values.
+func ObjectsFromFile(fsys fs.FS, file string, values TemplateValues) ([]*unstructured.Unstructured, error) {
+	data, err := fs.ReadFile(fsys, file)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err = RenderManifest(file, data, values)
+	if err != nil {
+		return nil, err
+	}
+
+	var objects []*unstructured.Unstructured
+
+	decoder := utilyaml.NewYAMLOrJSONDecoder(bytes.NewReader(data), 4096)
+	for {
+		obj := &unstructured.Unstructured{}
+		if err := decoder.Decode(&obj.Object); err != nil {
+			if err == io.EOF {
+				break
+			}
+
+			return nil, fmt.Errorf("error decoding %v: %v", file, err)
+		}
+
+		// empty documents or containing only comments
+		if len(obj.Object) == 0 {
+			continue
+		}
+
+		if obj.GetKind() == "" || obj.GetAPIVersion() == "" {
+			return nil, fmt.Errorf("file %v contains an object without apiVersion or kind", file)
+		}
+
+		objects = append(objects, obj)
+	}
+
+	return objects, nil
+}
+
+// ObjectsFromPath returns the objects defined in a .json/yaml file of the filesystem or,
+// if name is a directory, in the files of the directory sorted by name.
+func ObjectsFromPath(fsys fs.FS, name string, values TemplateValues) ([]*unstructured.Unstructured, error) {
+	if !isDirFS(fsys, name) {
+		return ObjectsFromFile(fsys, name, values)
+	}
+
+	files, err := fs.ReadDir(fsys, name)
+	if err != nil {
+		return nil, err
+	}
+
+	var objects []*unstructured.Unstructured
+	for _, file := range files {
+		ext := strings.ToLower(path.Ext(file.Name()))
+		if file.IsDir() || (ext != ".yaml" && ext != ".yml" && ext != ".json") {
+			continue
+		}
+
+		fileObjects, err := ObjectsFromFile(fsys, path.Join(name, file.Name()), values)
+		if err != nil {
+			return nil, err
+		}
+
+		objects = append(objects, fileObjects...)
+	}
+
+	return objects, nil
+}
+
+// Apply creates or updates the objects in the namespace in dependency order:
+// CRDs, IngressClasses, ConfigMaps and Secrets, Services and workloads. Then waits
+// until the workloads are available and services have endpoints before applying
+// Ingresses and other kinds, returning the first Ingress (if any).
+func (oc *ObjectClient) Apply(namespace string, objects []*unstructured.Unstructured) (*networkingv1.Ingress, error) {
+	objects = append([]*unstructured.Unstructured{}, objects...)
+	sort.SliceStable(objects, func(i, j int) bool {
+		return orderOf(objects[i]) < orderOf(objects[j])
+	})
+
+	var (
+		ingress *networkingv1.Ingress
+		ready   []func() error
+		waited  bool
+	)
+
+	for _, obj := range objects {
+		if !waited && orderOf(obj) == defaultKindOrder {
+			for _, waitFn := range ready {
+				if err := waitFn(); err != nil {
+					return nil, err
+				}
+			}
+
+			waited = true
+		}
+
+		applied, err := oc.apply(namespace, obj)
+		if err != nil {
+			return nil, err
+		}
+
+		switch applied.GroupVersionKind().GroupKind() {
+		case ingressKind, legacyIngressKind:
+			ing, err := ingressFromUnstructured(applied)
+			if err != nil {
+				return nil, err
+			}
+
+			if ingress == nil {
+				ingress = ing
+			}
+		case serviceKind:
+			if selector, _, _ := unstructured.NestedMap(applied.Object, "spec", "selector"); len(selector) != 0 {
+				name := applied.GetName()
+				ready = append(ready, func() error {
+					return waitForServiceEndpoints(oc.client, namespace, name, WaitForObjectsTimeout)
+				})
+			}
+		case deploymentKind:
+			name := applied.GetName()
+			ready = append(ready, func() error {
+				return waitForDeployment(oc.client, namespace, name, WaitForObjectsTimeout)
+			})
+		case rcKind:
+			name := applied.GetName()
+			ready = append(ready, func() error {
+				return waitForReplicationController(oc.client, namespace, name, WaitForObjectsTimeout)
+			})
+		}
+	}
+
+	if !waited {
+		for _, waitFn := range ready {
+			if err := waitFn(); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return ingress, nil
+}
+
+// ApplyFromPath applies the objects defined in a file or directory located in Manifests
+func (oc *ObjectClient) ApplyFromPath(file, namespace string, values TemplateValues) (*networkingv1.Ingress, error) {
+	objects, err := ObjectsFromPath(Manifests, file, values)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(objects) == 0 {
+		return nil, fmt.Errorf("%v does not contain manifests", file)
+	}
+
+	return oc.Apply(namespace, objects)
+}
+
+// apply creates or updates an object using server-side apply
+func (oc *ObjectClient) apply(namespace string, obj *unstructured.Unstructured) (*unstructured.Unstructured, error) {
+	obj, err := prepareObject(namespace, obj)
+	if err != nil {
+		return nil, err
+	}
+
+	gvk := obj.GroupVersionKind()
+
+	mapping, err := oc.restMapping(gvk.GroupKind(), gvk.Version)
+	if err != nil {
+		return nil, fmt.Errorf("unable to find the resource for %v: %v", gvk, err)
+	}
+
+	var resource dynamic.ResourceInterface = oc.dynamic.Resource(mapping.Resource)
+	if mapping.Scope.Name() == meta.RESTScopeNameNamespace {
+		obj.SetNamespace(namespace)
+		resource = oc.dynamic.Resource(mapping.Resource).Namespace(namespace)
+	}
+
+	data, err := json.Marshal(obj)
+	if err != nil {
+		return nil, err
+	}
+
+	force := true
+	applied, err := resource.Patch(context.TODO(), obj.GetName(), types.ApplyPatchType, data, metav1.PatchOptions{
+		FieldManager: FieldManager,
+		Force:        &force,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("unable to apply %v %v: %v", gvk.Kind, obj.GetName(), err)
+	}
+
+	return applied, nil
+}
+
+// restMapping returns the resource of a kind. Kinds not found (i.e. CRDs just
+// created) are retried after refreshing the discovery information.
+func (oc *ObjectClient) restMapping(gk schema.GroupKind, version string) (*meta.RESTMapping, error) {
+	var mapping *meta.RESTMapping
+
+	err := wait.PollImmediate(time.Second, waitForMappingTimeout, func() (bool, error) {
+		var err error
+
+		mapping, err = oc.mapper.RESTMapping(gk, version)
+		if meta.IsNoMatchError(err) {
+			oc.mapper.Reset()
+			return false, nil
+		}
+
+		return err == nil, err
+	})
+
+	return mapping, err
+}
+
+// prepareObject returns a copy of the object ready to be applied. Ingresses and
+// IngressClasses are converted to the version served by the cluster and
+// Ingresses use the class configured in the suite. Custom resources using
+// the same kinds are not modified.
+func prepareObject(namespace string, obj *unstructured.Unstructured) (*unstructured.Unstructured, error) {
+	obj = obj.DeepCopy()
+
+	switch obj.GroupVersionKind().GroupKind() {
+	case ingressKind, legacyIngressKind:
+		ing, err := ingressFromUnstructured(obj)
+		if err != nil {
+			return nil, err
+		}
+
+		SetIngressClass(ing)
+
+		if IsIngressV1() {
+			return toUnstructured(withPathType(ing))
+		}
+
+		return toUnstructured(IngressToV1beta1(ing))
+	case ingressClassKind:
+		class, err := ingressClassFromUnstructured(obj)
+		if err != nil {
+			return nil, err
+		}
+
+		if class.Labels == nil {
+			class.Labels = map[string]string{}
+		}
+
+		class.Labels[ScenarioNamespaceLabel] = namespace
+
+		if IsIngressV1() {
+			class.TypeMeta = metav1.TypeMeta{Kind: "IngressClass", APIVersion: networkingv1.SchemeGroupVersion.String()}
+			return toUnstructured(class)
+		}
+
+		v1beta1Class := ingressClassToV1beta1(class)
+		v1beta1Class.TypeMeta = metav1.TypeMeta{Kind: "IngressClass", APIVersion: networkingv1beta1.SchemeGroupVersion.String()}
+
+		return toUnstructured(v1beta1Class)
+	}
+
+	return obj, nil
+}
+
+// ingressFromUnstructured returns the networking.k8s.io/v1 representation of an Ingress
+func ingressFromUnstructured(obj *unstructured.Unstructured) (*networkingv1.Ingress, error) {
+	switch obj.GetAPIVersion() {
+	case networkingv1.SchemeGroupVersion.String():
+		ing := &networkingv1.Ingress{}
+		err := runtime.DefaultUnstructuredConverter.FromUnstructured(obj.Object, ing)
+		return ing, err
+	// extensions/v1beta1 defines the same fields as networking.k8s.io/v1beta1
+	case networkingv1beta1.SchemeGroupVersion.String(), "extensions/v1beta1":
+		ing := &networkingv1beta1.Ingress{}
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(obj.Object, ing); err != nil {
+			return nil, err
+		}
+
+		return IngressFromV1beta1(ing), nil
+	default:
+		return nil, fmt.Errorf("unsupported Ingress version %v", obj.GetAPIVersion())
+	}
+}
+
+// ingressClassFromUnstructured returns the networking.k8s.io/v1 representation of an IngressClass
+func ingressClassFromUnstructured(obj *unstructured.Unstructured) (*networkingv1.IngressClass, error) {
+	switch obj.GetAPIVersion() {
+	case networkingv1.SchemeGroupVersion.String():
+		class := &networkingv1.IngressClass{}
+		err := runtime.DefaultUnstructuredConverter.FromUnstructured(obj.Object, class)
+		return class, err
+	case networkingv1beta1.SchemeGroupVersion.String():
+		class := &networkingv1beta1.IngressClass{}
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(obj.Object, class); err != nil {
+			return nil, err
+		}
+
+		return ingressClassFromV1beta1(class), nil
+	default:
+		return nil, fmt.Errorf("unsupported IngressClass version %v", obj.GetAPIVersion())
+	}
+}
+
+// toUnstructured converts a typed object removing the fields not
+// defined in manifests (status and creation timestamp).
+func toUnstructured(obj runtime.Object) (*unstructured.Unstructured, error) {
+	content, err := runtime.DefaultUnstructuredConverter.ToUnstructured(obj)
+	if err != nil {
+		return nil, err
+	}
+
+	u := &unstructured.Unstructured{Object: content}
+	unstructured.RemoveNestedField(u.Object, "status")
+	unstructured.RemoveNestedField(u.Object, "metadata", "creationTimestamp")
+
+	return u, nil
+}
+
+func orderOf(obj *unstructured.Unstructured) int {
+	if order, ok := kindOrder[obj.GroupVersionKind().GroupKind()]; ok {
+		return order
+	}
+
+	return defaultKindOrder
+}
+
+// waitForDeployment waits until all the replicas of the deployment are available.
+func waitForDeployment(c kubernetes.Interface, namespace, name string, timeout time.Duration) error {
+	err := wait.PollImmediate(2*time.Second, timeout, func() (bool, error) {
+		d, err := c.AppsV1().Deployments(namespace).Get(context.TODO(), name, metav1.GetOptions{})
+		if err != nil {
+			return false, err
+		}
+
+		replicas := int32(1)
+		if d.Spec.Replicas != nil {
+			replicas = *d.Spec.Replicas
+		}
+
+		return d.Status.ObservedGeneration >= d.Generation && d.Status.AvailableReplicas >= replicas, nil
+	})
+	if err != nil {
+		return fmt.Errorf("waiting for deployment %v to be available: %v", name, err)
+	}
+
+	return nil
+}
+
+// waitForReplicationController waits until all the replicas of the replication controller are ready.
+func waitForReplicationController(c kubernetes.Interface, namespace, name string, timeout time.Duration) error {
+	err := wait.PollImmediate(2*time.Second, timeout, func() (bool, error) {
+		rc, err := c.CoreV1().ReplicationControllers(namespace).Get(context.TODO(), name, metav1.GetOptions{})
+		if err != nil {
+			return false, err
+		}
+
+		replicas := int32(1)
+		if rc.Spec.Replicas != nil {
+			replicas = *rc.Spec.Replicas
+		}
+
+		return rc.Status.ReadyReplicas >= replicas, nil
+	})
+	if err != nil {
+		return fmt.Errorf("waiting for replication controller %v to be ready: %v", name, err)
+	}
+
+	return nil
+}
+
+// waitForServiceEndpoints waits until the service has at least one endpoint.
+func waitForServiceEndpoints(c kubernetes.Interface, namespace, name string, timeout time.Duration) error {
+	err := wait.PollImmediate(2*time.Second, timeout, func() (bool, error) {
+		e, err := c.CoreV1().Endpoints(namespace).Get(context.TODO(), name, metav1.GetOptions{})
+		if err != nil {
+			if IsRetryableAPIError(err) || apierrors.IsNotFound(err) {
+				return false, nil
+			}
+
+			return false, err
+		}
+
+		return countEndpointsNum(e) > 0, nil
+	})
+	if err != nil {
+		return fmt.Errorf("waiting for endpoints of service %v: %v", name, err)
+	}
+
+	return nil
+}
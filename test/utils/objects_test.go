@@ -0,0 +1,119 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package utils_test
+
+import (
+	"context"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/aledbf/ingress-conformance-bdd/test/harness"
+	"github.com/aledbf/ingress-conformance-bdd/test/utils"
+)
+
+func newCluster(t *testing.T) (*harness.Cluster, string) {
+	utils.IngressClassValue = "conformance"
+	utils.IngressWaitInterval = 100 * time.Millisecond
+
+	cluster := harness.NewCluster()
+	t.Cleanup(cluster.Close)
+
+	if err := cluster.Install(os.DirFS("../../manifests")); err != nil {
+		t.Fatalf("unexpected error configuring the fake cluster: %v", err)
+	}
+
+	namespace, err := utils.CreateTestNamespace(cluster.Client)
+	if err != nil {
+		t.Fatalf("unexpected error creating namespace: %v", err)
+	}
+
+	return cluster, namespace
+}
+
+func TestCreateFromPath(t *testing.T) {
+	cluster, namespace := newCluster(t)
+
+	ing, err := utils.CreateFromPath(cluster.Objects, "scenarios/008", namespace, utils.NewTemplateValues(namespace))
+	if err != nil {
+		t.Fatalf("unexpected error creating objects: %v", err)
+	}
+
+	if ing == nil || ing.Name != "echo" {
+		t.Fatalf("expected Ingress echo but %v was returned", ing)
+	}
+
+	if ing.Annotations[utils.IngressClassKey] != utils.IngressClassValue {
+		t.Errorf("expected Ingress with class %v but got %v", utils.IngressClassValue, ing.Annotations)
+	}
+
+	if _, err := cluster.Client.AppsV1().Deployments(namespace).Get(context.TODO(), "echo", metav1.GetOptions{}); err != nil {
+		t.Errorf("expected deployment echo: %v", err)
+	}
+
+	address, err := utils.WaitForIngressAddress(cluster.Client, namespace, ing.Name, utils.WaitForIngressAddressTimeout)
+	if err != nil {
+		t.Fatalf("unexpected error waiting for the address: %v", err)
+	}
+
+	if address != cluster.Controller.Address() {
+		t.Errorf("expected address %v but %v was returned", cluster.Controller.Address(), address)
+	}
+}
+
+func TestApplyFromPathUpdatesObjects(t *testing.T) {
+	cluster, namespace := newCluster(t)
+
+	values := utils.NewTemplateValues(namespace)
+	values["Backend"] = "foo"
+
+	if _, err := cluster.Objects.ApplyFromPath("scenarios/018", namespace, values); err != nil {
+		t.Fatalf("unexpected error applying manifest: %v", err)
+	}
+
+	values["Backend"] = "bar"
+
+	ing, err := cluster.Objects.ApplyFromPath("scenarios/018", namespace, values)
+	if err != nil {
+		t.Fatalf("unexpected error applying manifest again: %v", err)
+	}
+
+	ing, err = utils.GetIngress(cluster.Client, namespace, ing.Name)
+	if err != nil {
+		t.Fatalf("unexpected error reading the Ingress: %v", err)
+	}
+
+	if backend := ing.Spec.Rules[0].HTTP.Paths[0].Backend.Service.Name; backend != "bar" {
+		t.Errorf("expected Ingress using the service bar but got %v", backend)
+	}
+
+	if host := ing.Spec.Rules[0].Host; host != values["Host"] {
+		t.Errorf("expected Ingress using the host %v but got %v", values["Host"], host)
+	}
+}
+
+func TestApplyFromPathMissingValue(t *testing.T) {
+	cluster, namespace := newCluster(t)
+
+	_, err := cluster.Objects.ApplyFromPath("scenarios/018", namespace, utils.NewTemplateValues(namespace))
+	if err == nil || !strings.Contains(err.Error(), "Backend") {
+		t.Fatalf("expected an error about the missing value Backend but got %v", err)
+	}
+}
@@ -0,0 +1,198 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package utils
+
+import (
+	"context"
+	"fmt"
+
+	authorizationv1 "k8s.io/api/authorization/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	// ConformanceRBACName is the name of the service account, cluster role and
+	// cluster role binding used to run the suite in a pod
+	ConformanceRBACName = "ingress-conformance"
+	// ConformanceNamespace is the default namespace of the pod running the suite
+	ConformanceNamespace = "ingress-conformance"
+)
+
+// ConformanceLabels identify the objects created to run the suite in a pod. The name must be
+// different from the label of the scenario namespaces (ingress-conformance),
+// removed by the suite before running the features.
+var ConformanceLabels = map[string]string{
+	NamespaceNameLabel:            "ingress-conformance-runner",
+	"app.kubernetes.io/component": "runner",
+}
+
+// ConformancePolicyRules contains the permissions required to run the conformance
+// suite. Scenarios run in temporal namespaces, so the permissions are cluster wide.
+// The ClusterRole in images/conformance/conformance.yaml is generated from these
+// rules (make rbac). Objects defined in manifests are created using server-side
+// apply (patch). Manifests containing custom resources require additional rules.
+var ConformancePolicyRules = []rbacv1.PolicyRule{
+	{
+		APIGroups: []string{""},
+		Resources: []string{"namespaces"},
+		Verbs:     []string{"get", "list", "create", "delete"},
+	},
+	{
+		APIGroups: []string{""},
+		Resources: []string{"services"},
+		Verbs:     []string{"get", "create", "patch"},
+	},
+	{
+		APIGroups: []string{""},
+		Resources: []string{"endpoints"},
+		Verbs:     []string{"get", "list"},
+	},
+	{
+		APIGroups: []string{""},
+		Resources: []string{"replicationcontrollers"},
+		Verbs:     []string{"get", "create", "patch"},
+	},
+	{
+		APIGroups: []string{""},
+		Resources: []string{"configmaps", "secrets"},
+		Verbs:     []string{"create", "patch"},
+	},
+	{
+		APIGroups: []string{""},
+		Resources: []string{"pods"},
+		Verbs:     []string{"get", "create"},
+	},
+	{
+		APIGroups: []string{""},
+		Resources: []string{"nodes"},
+		Verbs:     []string{"list"},
+	},
+	{
+		APIGroups: []string{"apps"},
+		Resources: []string{"deployments"},
+		Verbs:     []string{"get", "create", "patch"},
+	},
+	{
+		APIGroups: []string{"networking.k8s.io"},
+		Resources: []string{"ingresses"},
+		Verbs:     []string{"get", "create", "patch"},
+	},
+	{
+		APIGroups: []string{"networking.k8s.io"},
+		Resources: []string{"ingressclasses"},
+		Verbs:     []string{"get", "list", "create", "update", "patch", "delete"},
+	},
+}
+
+// ConformanceClusterRole returns the cluster role with the rules required to run the suite.
+// The cluster role created by cmd/ingress-conformance and the manifest generated by
+// hack/rbac use this definition.
+func ConformanceClusterRole() *rbacv1.ClusterRole {
+	return &rbacv1.ClusterRole{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   ConformanceRBACName,
+			Labels: ConformanceLabels,
+		},
+		Rules: ConformancePolicyRules,
+	}
+}
+
+// ConformanceClusterRoleBinding returns the binding of the cluster role
+// returned by ConformanceClusterRole to the service account of the namespace.
+func ConformanceClusterRoleBinding(namespace string) *rbacv1.ClusterRoleBinding {
+	return &rbacv1.ClusterRoleBinding{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   ConformanceRBACName,
+			Labels: ConformanceLabels,
+		},
+		RoleRef: rbacv1.RoleRef{
+			APIGroup: rbacv1.GroupName,
+			Kind:     "ClusterRole",
+			Name:     ConformanceRBACName,
+		},
+		Subjects: []rbacv1.Subject{
+			{
+				Kind:      rbacv1.ServiceAccountKind,
+				Name:      ConformanceRBACName,
+				Namespace: namespace,
+			},
+		},
+	}
+}
+
+// Permission is a verb allowed in a resource
+type Permission struct {
+	Group    string
+	Resource string
+	Verb     string
+}
+
+func (p Permission) String() string {
+	if p.Group == "" {
+		return fmt.Sprintf("%v %v", p.Verb, p.Resource)
+	}
+
+	return fmt.Sprintf("%v %v.%v", p.Verb, p.Resource, p.Group)
+}
+
+// MissingPermissions checks the rules using SelfSubjectAccessReview
+// and returns the permissions not granted to the current user.
+func MissingPermissions(c kubernetes.Interface, rules []rbacv1.PolicyRule) ([]Permission, error) {
+	var missing []Permission
+
+	for _, rule := range rules {
+		for _, group := range rule.APIGroups {
+			for _, resource := range rule.Resources {
+				for _, verb := range rule.Verbs {
+					permission := Permission{Group: group, Resource: resource, Verb: verb}
+
+					allowed, err := isAllowed(c, permission)
+					if err != nil {
+						return nil, err
+					}
+
+					if !allowed {
+						missing = append(missing, permission)
+					}
+				}
+			}
+		}
+	}
+
+	return missing, nil
+}
+
+func isAllowed(c kubernetes.Interface, permission Permission) (bool, error) {
+	review := &authorizationv1.SelfSubjectAccessReview{
+		Spec: authorizationv1.SelfSubjectAccessReviewSpec{
+			ResourceAttributes: &authorizationv1.ResourceAttributes{
+				Group:    permission.Group,
+				Resource: permission.Resource,
+				Verb:     permission.Verb,
+			},
+		},
+	}
+
+	resp, err := c.AuthorizationV1().SelfSubjectAccessReviews().Create(context.TODO(), review, metav1.CreateOptions{})
+	if err != nil {
+		return false, fmt.Errorf("unexpected error checking permission %v: %v", permission, err)
+	}
+
+	return resp.Status.Allowed, nil
+}
@@ -0,0 +1,147 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package utils
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	authorizationv1 "k8s.io/api/authorization/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+// fakeAccessReviews returns a client granting the permissions of the map
+func fakeAccessReviews(allowed map[Permission]bool, reviewErr error) *fake.Clientset {
+	client := fake.NewSimpleClientset()
+	client.PrependReactor("create", "selfsubjectaccessreviews", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		if reviewErr != nil {
+			return true, nil, reviewErr
+		}
+
+		review := action.(k8stesting.CreateAction).GetObject().(*authorizationv1.SelfSubjectAccessReview)
+		attributes := review.Spec.ResourceAttributes
+
+		review = review.DeepCopy()
+		review.Status.Allowed = allowed[Permission{Group: attributes.Group, Resource: attributes.Resource, Verb: attributes.Verb}]
+
+		return true, review, nil
+	})
+
+	return client
+}
+
+func TestMissingPermissions(t *testing.T) {
+	rules := []rbacv1.PolicyRule{
+		{
+			APIGroups: []string{""},
+			Resources: []string{"namespaces", "services"},
+			Verbs:     []string{"get", "create"},
+		},
+		{
+			APIGroups: []string{"networking.k8s.io"},
+			Resources: []string{"ingresses"},
+			Verbs:     []string{"patch"},
+		},
+	}
+
+	all := map[Permission]bool{
+		{Resource: "namespaces", Verb: "get"}:                              true,
+		{Resource: "namespaces", Verb: "create"}:                           true,
+		{Resource: "services", Verb: "get"}:                                true,
+		{Resource: "services", Verb: "create"}:                             true,
+		{Group: "networking.k8s.io", Resource: "ingresses", Verb: "patch"}: true,
+	}
+
+	testCases := []struct {
+		name     string
+		allowed  map[Permission]bool
+		expected []Permission
+	}{
+		{
+			name:    "all the permissions granted",
+			allowed: all,
+		},
+		{
+			name: "missing permissions",
+			allowed: map[Permission]bool{
+				{Resource: "namespaces", Verb: "get"}: true,
+				{Resource: "services", Verb: "get"}:   true,
+			},
+			expected: []Permission{
+				{Resource: "namespaces", Verb: "create"},
+				{Resource: "services", Verb: "create"},
+				{Group: "networking.k8s.io", Resource: "ingresses", Verb: "patch"},
+			},
+		},
+		{
+			name: "no permissions",
+			expected: []Permission{
+				{Resource: "namespaces", Verb: "get"},
+				{Resource: "namespaces", Verb: "create"},
+				{Resource: "services", Verb: "get"},
+				{Resource: "services", Verb: "create"},
+				{Group: "networking.k8s.io", Resource: "ingresses", Verb: "patch"},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			missing, err := MissingPermissions(fakeAccessReviews(tc.allowed, nil), rules)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if !reflect.DeepEqual(missing, tc.expected) {
+				t.Errorf("expected missing permissions %v but got %v", tc.expected, missing)
+			}
+		})
+	}
+}
+
+func TestMissingPermissionsError(t *testing.T) {
+	_, err := MissingPermissions(fakeAccessReviews(nil, fmt.Errorf("forbidden")), ConformancePolicyRules)
+	if err == nil {
+		t.Fatalf("expected an error creating the SelfSubjectAccessReview")
+	}
+}
+
+func TestPermissionString(t *testing.T) {
+	testCases := []struct {
+		permission Permission
+		expected   string
+	}{
+		{
+			permission: Permission{Resource: "services", Verb: "get"},
+			expected:   "get services",
+		},
+		{
+			permission: Permission{Group: "networking.k8s.io", Resource: "ingresses", Verb: "patch"},
+			expected:   "patch ingresses.networking.k8s.io",
+		},
+	}
+
+	for _, tc := range testCases {
+		if tc.permission.String() != tc.expected {
+			t.Errorf("expected %v but got %v", tc.expected, tc.permission.String())
+		}
+	}
+}
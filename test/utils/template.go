@@ -0,0 +1,78 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package utils
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"text/template"
+
+	"k8s.io/apimachinery/pkg/util/rand"
+)
+
+// TemplateValues contains the variables available in manifests. Manifests
+// are rendered using text/template, i.e. {{.Namespace}} or {{.Host}}.
+type TemplateValues map[string]string
+
+// NewTemplateValues returns the variables defined for all the scenarios:
+//
+//	Namespace:    namespace of the scenario
+//	IngressClass: class of the Ingresses used by the suite
+//	Suffix:       random suffix to generate unique names
+//	Host:         host unique to the scenario (<namespace>.ingress-conformance)
+//	EchoImage:    image of the conformance echo server (flag --echo-image)
+//
+// The values can be replaced or extended using values from step tables.
+func NewTemplateValues(namespace string) TemplateValues {
+	return TemplateValues{
+		"Namespace":    namespace,
+		"IngressClass": IngressClassValue,
+		"Suffix":       rand.String(5),
+		"Host":         fmt.Sprintf("%v.ingress-conformance", namespace),
+		"EchoImage":    EchoServerImage,
+	}
+}
+
+// RenderManifest renders the content of a manifest using the values.
+// Referencing a variable not defined in values returns an error. If
+// values is nil, variables are replaced by empty strings (this allows
+// inspecting manifests outside of a scenario).
+func RenderManifest(name string, data []byte, values TemplateValues) ([]byte, error) {
+	missingKey := "missingkey=error"
+	if values == nil {
+		missingKey = "missingkey=zero"
+	}
+
+	tmpl, err := template.New(name).
+		Option(missingKey).
+		Funcs(template.FuncMap{
+			"lower": strings.ToLower,
+			"upper": strings.ToUpper,
+		}).
+		Parse(string(data))
+	if err != nil {
+		return nil, fmt.Errorf("error parsing template %v: %v", name, err)
+	}
+
+	buf := &bytes.Buffer{}
+	if err := tmpl.Execute(buf, values); err != nil {
+		return nil, fmt.Errorf("error rendering template %v: %v", name, err)
+	}
+
+	return buf.Bytes(), nil
+}
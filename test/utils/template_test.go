@@ -0,0 +1,114 @@
+/*
+Copyright 2020 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package utils
+
+import (
+	"testing"
+)
+
+func TestRenderManifest(t *testing.T) {
+	testCases := []struct {
+		name     string
+		template string
+		values   TemplateValues
+		expected string
+		err      bool
+	}{
+		{
+			name:     "static manifest",
+			template: "kind: Service",
+			values:   TemplateValues{},
+			expected: "kind: Service",
+		},
+		{
+			name:     "variables",
+			template: "host: {{.Host}}\nnamespace: {{.Namespace}}",
+			values:   TemplateValues{"Host": "foo.bar", "Namespace": "test"},
+			expected: "host: foo.bar\nnamespace: test",
+		},
+		{
+			name:     "functions",
+			template: "host: {{lower .Host}}",
+			values:   TemplateValues{"Host": "FOO.Bar"},
+			expected: "host: foo.bar",
+		},
+		{
+			name:     "missing value",
+			template: "host: {{.Host}}",
+			values:   TemplateValues{},
+			err:      true,
+		},
+		{
+			name:     "missing value without values",
+			template: "host: {{.Host}}",
+			expected: "host: ",
+		},
+		{
+			name:     "invalid template",
+			template: "host: {{.Host",
+			values:   TemplateValues{},
+			err:      true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			data, err := RenderManifest(tc.name, []byte(tc.template), tc.values)
+			if tc.err {
+				if err == nil {
+					t.Fatalf("expected an error but got %q", data)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if string(data) != tc.expected {
+				t.Errorf("expected %q but got %q", tc.expected, data)
+			}
+		})
+	}
+}
+
+func TestNewTemplateValues(t *testing.T) {
+	IngressClassValue = "conformance"
+
+	values := NewTemplateValues("test")
+
+	if values["Namespace"] != "test" {
+		t.Errorf("expected namespace test but got %v", values["Namespace"])
+	}
+
+	if values["IngressClass"] != "conformance" {
+		t.Errorf("expected class conformance but got %v", values["IngressClass"])
+	}
+
+	if values["Host"] != "test.ingress-conformance" {
+		t.Errorf("expected host test.ingress-conformance but got %v", values["Host"])
+	}
+
+	if values["EchoImage"] != EchoServerImage {
+		t.Errorf("expected echo image %v but got %v", EchoServerImage, values["EchoImage"])
+	}
+
+	if len(values["Suffix"]) != 5 {
+		t.Errorf("expected a random suffix of 5 characters but got %v", values["Suffix"])
+	}
+}